@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Run the exports and generates of the project config",
+	RunE:  buildFunc,
+}
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+}
+
+func buildFunc(cmd *cobra.Command, args []string) error {
+	if config == nil {
+		return errors.New(exceref.ConfigFileName + " needs to be provided")
+	}
+	if len(config.Workbooks) == 0 {
+		return errors.New("workbooks needs to be provided in the config")
+	}
+
+	openOption, err := config.OpenOption()
+	if err != nil {
+		return errs.Wrap(err, "build open option")
+	}
+	paths, err := exceref.FindWorkbooks(config.Workbooks)
+	if err != nil {
+		return errs.Wrap(err, "find workbooks")
+	}
+	project, err := exceref.OpenProject(paths, openOption)
+	if err != nil {
+		return errs.Wrap(err, "open project")
+	}
+	defer project.Close()
+
+	if config.Update {
+		for _, file := range project.Files() {
+			if err := file.UpdateReferenceData(); err != nil {
+				return errs.Wrap(err, "update reference data")
+			}
+			if err := file.UpdateDataValidations(); err != nil {
+				return errs.Wrap(err, "update data validations")
+			}
+			if err := file.Save(); err != nil {
+				return errs.Wrap(err, "save file")
+			}
+		}
+	}
+
+	for i, export := range config.Exports {
+		option, err := export.ExportOption()
+		if err != nil {
+			return errs.Wrap(err, fmt.Sprintf("build exports[%d] option", i))
+		}
+		if err := os.MkdirAll(option.OutDir, 0o755); err != nil {
+			return errs.Wrap(err, "create output directory")
+		}
+		if err := project.Export(exceref.BuildExporter(export.Format, option), export.SheetFilter()); err != nil {
+			return errs.Wrap(err, fmt.Sprintf("run exports[%d]", i))
+		}
+	}
+	for i, generate := range config.Generates {
+		option := generate.GenerateOption()
+		if err := os.MkdirAll(option.OutDir, 0o755); err != nil {
+			return errs.Wrap(err, "create output directory")
+		}
+		if err := project.Generate(exceref.BuildGenerator(generate.Lang, option), generate.SheetFilter()); err != nil {
+			return errs.Wrap(err, fmt.Sprintf("run generates[%d]", i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [FILE|DIR|GLOB...]",
+	Short: "Validate workbooks without writing any output",
+	RunE:  checkFunc,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringP("format", "f", "human", "Set report format: human, json, junit or github")
+	addOpenFlags(checkCmd)
+}
+
+// Problem is a failure found by check, located at a cell of a workbook when known.
+type Problem struct {
+	File    string `json:"file,omitempty"`
+	Sheet   string `json:"sheet,omitempty"`
+	Cell    string `json:"cell,omitempty"`
+	Message string `json:"message"`
+}
+
+// SheetLocation returns the location within the workbook in the Sheet!A1 form.
+func (p Problem) SheetLocation() string {
+	if p.Cell == "" {
+		return p.Sheet
+	}
+	return p.Sheet + "!" + p.Cell
+}
+
+// Location returns the location in the file:Sheet!A1 form of errs.CellError.
+func (p Problem) Location() string {
+	if p.File == "" {
+		return p.SheetLocation()
+	}
+	return p.File + ":" + p.SheetLocation()
+}
+
+func (p Problem) String() string {
+	if location := p.Location(); location != "" {
+		return location + ": " + p.Message
+	}
+	return p.Message
+}
+
+// newProblems flattens err into problems. paths maps the file names of the cell errors to the workbook paths.
+func newProblems(err error, paths map[string]string) []Problem {
+	var problems []Problem
+	for _, e := range errs.Flatten(err) {
+		var cellError *errs.CellError
+		if !errors.As(e, &cellError) {
+			problems = append(problems, Problem{Message: e.Error()})
+			continue
+		}
+		file := cellError.File
+		if path, ok := paths[file]; ok {
+			file = path
+		}
+		problems = append(problems, Problem{File: file, Sheet: cellError.Sheet, Cell: cellError.Cell, Message: cellError.Err.Error()})
+	}
+	return problems
+}
+
+func checkFunc(cmd *cobra.Command, args []string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return errs.Wrap(err, "get format flag")
+	}
+	write, ok := reportWriters[format]
+	if !ok {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+	if len(args) == 0 && config != nil {
+		args = config.Workbooks
+	}
+	if len(args) == 0 {
+		return errors.New("FILE, DIR or GLOB needs to be provided")
+	}
+	openOption, err := getOpenOption(cmd)
+	if err != nil {
+		return err
+	}
+	paths, err := exceref.FindWorkbooks(args)
+	if err != nil {
+		return errs.Wrap(err, "find workbooks")
+	}
+
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		files[filepath.Base(path)] = filepath.ToSlash(path)
+	}
+	problems := newProblems(checkWorkbooks(paths, openOption), files)
+	if err := write(cmd.OutOrStdout(), files, problems); err != nil {
+		return errs.Wrap(err, "write report")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+	return nil
+}
+
+func checkWorkbooks(paths []string, option exceref.OpenOption) error {
+	project, err := exceref.OpenProject(paths, option)
+	if err != nil {
+		return err
+	}
+	defer project.Close()
+
+	return project.Check()
+}
+
+// reportWriters write the problems found in the workbooks, which are keyed by their file names.
+var reportWriters = map[string]func(w io.Writer, files map[string]string, problems []Problem) error{
+	"human":  writeHumanReport,
+	"json":   writeJSONReport,
+	"junit":  writeJUnitReport,
+	"github": writeGitHubReport,
+}
+
+func writeHumanReport(w io.Writer, files map[string]string, problems []Problem) error {
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	_, err := fmt.Fprintf(w, "%d workbooks checked, %d problems found\n", len(files), len(problems))
+	return err
+}
+
+func writeJSONReport(w io.Writer, files map[string]string, problems []Problem) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(struct {
+		Workbooks int       `json:"workbooks"`
+		Problems  []Problem `json:"problems"`
+	}{
+		Workbooks: len(files),
+		Problems:  append([]Problem{}, problems...),
+	})
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes a test suite for each workbook with a failing test case for each problem,
+// or a single passing test case for a workbook without problems.
+func writeJUnitReport(w io.Writer, files map[string]string, problems []Problem) error {
+	var names []string
+	suites := make(map[string]*junitTestSuite)
+	suite := func(name string) *junitTestSuite {
+		if s, ok := suites[name]; ok {
+			return s
+		}
+		suites[name] = &junitTestSuite{Name: name}
+		names = append(names, name)
+		return suites[name]
+	}
+	for _, path := range sortedValues(files) {
+		suite(path)
+	}
+	for _, problem := range problems {
+		s := suite(lo.Ternary(problem.File != "", problem.File, "exceref"))
+		name := lo.Ternary(problem.Sheet != "", problem.SheetLocation(), "check")
+		s.Cases = append(s.Cases, junitTestCase{
+			Name:      name,
+			ClassName: s.Name,
+			Failure:   &junitFailure{Message: problem.Message, Text: problem.String()},
+		})
+		s.Failures++
+	}
+
+	report := junitTestSuites{}
+	for _, name := range names {
+		s := suites[name]
+		if len(s.Cases) == 0 {
+			s.Cases = append(s.Cases, junitTestCase{Name: "check", ClassName: s.Name})
+		}
+		s.Tests = len(s.Cases)
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Suites = append(report.Suites, *s)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHubReport writes the problems as GitHub Actions workflow commands, which annotate the workbooks.
+func writeGitHubReport(w io.Writer, files map[string]string, problems []Problem) error {
+	for _, problem := range problems {
+		var properties []string
+		if problem.File != "" {
+			properties = append(properties, "file="+escapeGitHubProperty(problem.File))
+		}
+		if problem.Sheet != "" {
+			properties = append(properties, "title="+escapeGitHubProperty(problem.SheetLocation()))
+		}
+		command := "::error"
+		if len(properties) > 0 {
+			command += " " + strings.Join(properties, ",")
+		}
+		if _, err := fmt.Fprintf(w, "%s::%s\n", command, escapeGitHubData(problem.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+func sortedValues(m map[string]string) []string {
+	values := lo.Values(m)
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apperrs "github.com/daichirata/exceref/internal/errs"
+)
+
+var testProblems = []Problem{
+	{File: "books/items.xlsx", Sheet: "items", Cell: "B5", Message: "column:id invalid, value"},
+	{Message: "workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx"},
+}
+
+var testFiles = map[string]string{"items.xlsx": "books/items.xlsx", "units.xlsx": "books/units.xlsx"}
+
+func TestNewProblems(t *testing.T) {
+	t.Parallel()
+
+	err := apperrs.Wrap(errors.Join(
+		&apperrs.CellError{File: "items.xlsx", Sheet: "items", Cell: "B5", Err: errors.New("column:id invalid, value")},
+		apperrs.Wrap(errors.New("workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx"), "sort workbooks"),
+	), "check")
+	require.Equal(t, testProblems, newProblems(err, testFiles))
+	require.Empty(t, newProblems(nil, testFiles))
+}
+
+func TestReportWriters(t *testing.T) {
+	t.Parallel()
+
+	for format, want := range map[string]string{
+		"human": `books/items.xlsx:items!B5: column:id invalid, value
+workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx
+2 workbooks checked, 2 problems found
+`,
+		"json": `{
+  "workbooks": 2,
+  "problems": [
+    {
+      "file": "books/items.xlsx",
+      "sheet": "items",
+      "cell": "B5",
+      "message": "column:id invalid, value"
+    },
+    {
+      "message": "workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx"
+    }
+  ]
+}
+`,
+		"junit": `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2">
+  <testsuite name="books/items.xlsx" tests="1" failures="1">
+    <testcase name="items!B5" classname="books/items.xlsx">
+      <failure message="column:id invalid, value">books/items.xlsx:items!B5: column:id invalid, value</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="books/units.xlsx" tests="1" failures="0">
+    <testcase name="check" classname="books/units.xlsx"></testcase>
+  </testsuite>
+  <testsuite name="exceref" tests="1" failures="1">
+    <testcase name="check" classname="exceref">
+      <failure message="workbook reference cycle detected: a.xlsx -&gt; b.xlsx -&gt; a.xlsx">workbook reference cycle detected: a.xlsx -&gt; b.xlsx -&gt; a.xlsx</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		"github": `::error file=books/items.xlsx,title=items!B5::column:id invalid, value
+::error::workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx
+`,
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, reportWriters[format](&buf, testFiles, testProblems), format)
+		require.Equal(t, want, buf.String(), format)
+	}
+}
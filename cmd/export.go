@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 
 	"github.com/daichirata/exceref/internal/errs"
@@ -17,11 +19,19 @@ var exportCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
-	exportCmd.Flags().StringP("out", "o", "", "Set output directory")
-	exportCmd.Flags().StringP("format", "f", "csv", "Set output format")
-	exportCmd.Flags().StringP("prefix", "p", "", "Set output file name prefix")
+	addExportFlags(exportCmd)
+}
+
+func addExportFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("out", "o", "", "Set output directory")
+	cmd.Flags().StringP("format", "f", "csv", "Set output format: csv, json or yaml")
+	cmd.Flags().StringP("prefix", "p", "", "Set output file name prefix")
+	cmd.Flags().String("list-separator", "", "Set list value separator for csv output")
+	cmd.Flags().Bool("keyed", false, "Key json and yaml output by the primary key")
+	cmd.Flags().String("output-timezone", "", "Set timezone of exported datetime values")
+	addWorkbookFlags(cmd)
 
-	exportCmd.MarkFlagRequired("out")
+	cmd.MarkFlagRequired("out")
 }
 
 func exportFunc(cmd *cobra.Command, args []string) error {
@@ -29,24 +39,64 @@ func exportFunc(cmd *cobra.Command, args []string) error {
 		return errors.New("FILE needs to be provided")
 	}
 
-	outDir, err := cmd.Flags().GetString("out")
+	exporter, err := getExporter(cmd)
 	if err != nil {
-		return errs.Wrap(err, "get out flag")
+		return err
 	}
-	format, err := cmd.Flags().GetString("format")
+	filter, err := getSheetFilter(cmd)
 	if err != nil {
-		return errs.Wrap(err, "get format flag")
+		return err
 	}
-	prefix, err := cmd.Flags().GetString("prefix")
+	openOption, err := getOpenOption(cmd)
 	if err != nil {
-		return errs.Wrap(err, "get prefix flag")
+		return err
 	}
 
-	file, err := exceref.Open(args[0])
+	file, err := exceref.Open(args[0], openOption)
 	if err != nil {
 		return errs.Wrap(err, "open file")
 	}
 	defer file.Close()
 
-	return errs.Wrap(file.Export(exceref.BuildExporter(format, outDir, prefix)), "export sheets")
+	return errs.Wrap(file.Export(exporter, filter), "export sheets")
+}
+
+// getExporter builds the exporter from the flags added by addExportFlags.
+func getExporter(cmd *cobra.Command) (exceref.Exporter, error) {
+	outDir, err := cmd.Flags().GetString("out")
+	if err != nil {
+		return nil, errs.Wrap(err, "get out flag")
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, errs.Wrap(err, "get format flag")
+	}
+	if !lo.Contains(exceref.ExportFormats, format) {
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+	prefix, err := cmd.Flags().GetString("prefix")
+	if err != nil {
+		return nil, errs.Wrap(err, "get prefix flag")
+	}
+	listSeparator, err := cmd.Flags().GetString("list-separator")
+	if err != nil {
+		return nil, errs.Wrap(err, "get list-separator flag")
+	}
+	keyed, err := cmd.Flags().GetBool("keyed")
+	if err != nil {
+		return nil, errs.Wrap(err, "get keyed flag")
+	}
+	outputLocation, err := getLocation(cmd, "output-timezone")
+	if err != nil {
+		return nil, errs.Wrap(err, "get output-timezone flag")
+	}
+
+	option := exceref.ExportOption{
+		Prefix:          prefix,
+		OutDir:          outDir,
+		ListSeparator:   listSeparator,
+		KeyByPrimaryKey: keyed,
+		Location:        outputLocation,
+	}
+	return exceref.BuildExporter(format, option), nil
 }
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 
 	"github.com/daichirata/exceref/internal/errs"
@@ -17,13 +19,19 @@ var generateCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
-	generateCmd.Flags().StringP("out", "o", "", "Set output directory")
-	generateCmd.Flags().StringP("lang", "l", "go", "Set output format")
-	generateCmd.Flags().StringP("prefix", "p", "", "Set output model name prefix")
-	generateCmd.Flags().StringP("template", "t", "", "Set template path")
+	addGenerateFlags(generateCmd)
+}
+
+func addGenerateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("out", "o", "", "Set output directory")
+	cmd.Flags().StringP("lang", "l", "go", "Set output language: go, csharp or generic")
+	cmd.Flags().StringP("prefix", "p", "", "Set output model name prefix")
+	cmd.Flags().StringP("template", "t", "", "Set template path")
+	cmd.Flags().String("package", "", "Set package name of generated go enums")
+	addWorkbookFlags(cmd)
 
-	generateCmd.MarkFlagRequired("out")
-	generateCmd.MarkFlagRequired("template")
+	cmd.MarkFlagRequired("out")
+	cmd.MarkFlagRequired("template")
 }
 
 func generateFunc(cmd *cobra.Command, args []string) error {
@@ -31,34 +39,59 @@ func generateFunc(cmd *cobra.Command, args []string) error {
 		return errors.New("FILE needs to be provided")
 	}
 
+	generator, err := getGenerator(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := getSheetFilter(cmd)
+	if err != nil {
+		return err
+	}
+	openOption, err := getOpenOption(cmd)
+	if err != nil {
+		return err
+	}
+
+	file, err := exceref.Open(args[0], openOption)
+	if err != nil {
+		return errs.Wrap(err, "open file")
+	}
+	defer file.Close()
+
+	return errs.Wrap(file.Generate(generator, filter), "generate models")
+}
+
+// getGenerator builds the generator from the flags added by addGenerateFlags.
+func getGenerator(cmd *cobra.Command) (exceref.Generator, error) {
 	outDir, err := cmd.Flags().GetString("out")
 	if err != nil {
-		return errs.Wrap(err, "get out flag")
+		return nil, errs.Wrap(err, "get out flag")
 	}
 	lang, err := cmd.Flags().GetString("lang")
 	if err != nil {
-		return errs.Wrap(err, "get lang flag")
+		return nil, errs.Wrap(err, "get lang flag")
+	}
+	if !lo.Contains(exceref.GenerateLangs, lang) {
+		return nil, fmt.Errorf("unknown lang: %s", lang)
 	}
 	prefix, err := cmd.Flags().GetString("prefix")
 	if err != nil {
-		return errs.Wrap(err, "get prefix flag")
+		return nil, errs.Wrap(err, "get prefix flag")
 	}
 	templatePath, err := cmd.Flags().GetString("template")
 	if err != nil {
-		return errs.Wrap(err, "get template flag")
+		return nil, errs.Wrap(err, "get template flag")
+	}
+	pkg, err := cmd.Flags().GetString("package")
+	if err != nil {
+		return nil, errs.Wrap(err, "get package flag")
 	}
 
 	option := exceref.GenerateOption{
 		Prefix:       prefix,
 		OutDir:       outDir,
 		TemplatePath: templatePath,
+		Package:      pkg,
 	}
-
-	file, err := exceref.Open(args[0])
-	if err != nil {
-		return errs.Wrap(err, "open file")
-	}
-	defer file.Close()
-
-	return errs.Wrap(file.Generate(exceref.BuildGenerator(lang, option)), "generate models")
+	return exceref.BuildGenerator(lang, option), nil
 }
@@ -5,5 +5,10 @@ import (
 )
 
 func init() {
+	// The meta commands open workbooks like the other commands, with the open flags and the project config.
+	meta.OpenOption = getOpenOption
+	for _, cmd := range meta.Cmd.Commands() {
+		addOpenFlags(cmd)
+	}
 	rootCmd.AddCommand(meta.Cmd)
 }
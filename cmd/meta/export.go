@@ -32,7 +32,12 @@ func exportFunc(cmd *cobra.Command, args []string) error {
 		return errs.Wrap(err, "get out flag")
 	}
 
-	file, err := exceref.Open(args[0])
+	openOption, err := OpenOption(cmd)
+	if err != nil {
+		return err
+	}
+
+	file, err := exceref.Open(args[0], openOption)
 	if err != nil {
 		return errs.Wrap(err, "open file")
 	}
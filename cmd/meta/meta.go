@@ -2,9 +2,17 @@ package meta
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/daichirata/exceref/internal/exceref"
 )
 
 var Cmd = &cobra.Command{
 	Use:   "meta",
 	Short: "meta namespace",
 }
+
+// OpenOption returns the option to open workbooks with, built from the open flags and the project config.
+// It is set by the root command, which adds the open flags to the subcommands.
+var OpenOption = func(cmd *cobra.Command) (exceref.OpenOption, error) {
+	return exceref.OpenOption{}, nil
+}
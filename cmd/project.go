@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Build several workbooks together in dependency order",
+}
+
+var projectExportCmd = &cobra.Command{
+	Use:  "export DIR|GLOB...",
+	RunE: projectExportFunc,
+}
+
+var projectGenerateCmd = &cobra.Command{
+	Use:  "generate DIR|GLOB...",
+	RunE: projectGenerateFunc,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectExportCmd)
+	projectCmd.AddCommand(projectGenerateCmd)
+
+	addExportFlags(projectExportCmd)
+	addGenerateFlags(projectGenerateCmd)
+}
+
+func projectExportFunc(cmd *cobra.Command, args []string) error {
+	exporter, err := getExporter(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := getSheetFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	project, err := openProject(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer project.Close()
+
+	return errs.Wrap(project.Export(exporter, filter), "export sheets")
+}
+
+func projectGenerateFunc(cmd *cobra.Command, args []string) error {
+	generator, err := getGenerator(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := getSheetFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	project, err := openProject(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer project.Close()
+
+	return errs.Wrap(project.Generate(generator, filter), "generate models")
+}
+
+func openProject(cmd *cobra.Command, args []string) (*exceref.Project, error) {
+	if len(args) == 0 {
+		return nil, errors.New("DIR or GLOB needs to be provided")
+	}
+	openOption, err := getOpenOption(cmd)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := exceref.FindWorkbooks(args)
+	if err != nil {
+		return nil, errs.Wrap(err, "find workbooks")
+	}
+	project, err := exceref.OpenProject(paths, openOption)
+	if err != nil {
+		return nil, errs.Wrap(err, "open project")
+	}
+	return project, nil
+}
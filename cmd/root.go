@@ -5,17 +5,27 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
 )
 
-var debug bool
+var (
+	debug      bool
+	configPath string
+	// config is the project config, or nil if there is none.
+	config *exceref.Config
+)
 
 var rootCmd = &cobra.Command{
 	Use:           "exceref",
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if debug {
 			slog.SetDefault(slog.New(
 				slog.NewTextHandler(
@@ -24,11 +34,68 @@ var rootCmd = &cobra.Command{
 				)),
 			)
 		}
+		return loadConfig(cmd)
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "debug")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Set project config path (default "+exceref.ConfigFileName+" if present)")
+}
+
+// loadConfig loads the project config given by --config, or the one in the working directory if any,
+// and applies its flag defaults to the command.
+func loadConfig(cmd *cobra.Command) error {
+	path := configPath
+	if path == "" {
+		if _, err := os.Stat(exceref.ConfigFileName); err != nil {
+			return nil
+		}
+		path = exceref.ConfigFileName
+	}
+	c, err := exceref.LoadConfig(path)
+	if err != nil {
+		return errs.Wrap(err, "load config")
+	}
+	config = c
+	return errs.Wrap(applyConfigFlags(cmd, config), "apply config flags")
+}
+
+// applyConfigFlags sets the flags that are not given on the command line from the config.
+// The flags section of the command takes precedence over the timezone and evaluate_formulas of the project.
+func applyConfigFlags(cmd *cobra.Command, config *exceref.Config) error {
+	defaults := make(map[string]string)
+	if config.Timezone != "" {
+		defaults["timezone"] = config.Timezone
+	}
+	if config.EvaluateFormulas {
+		defaults["evaluate-formulas"] = "true"
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	for key, value := range config.Flags[name] {
+		if cmd.Flags().Lookup(key) == nil {
+			return fmt.Errorf("command:%s unknown flag: %s", name, key)
+		}
+		defaults[key] = value
+	}
+	for key, value := range defaults {
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(key, value); err != nil {
+			return fmt.Errorf("command:%s flag:%s %w", name, key, err)
+		}
+	}
+	return nil
+}
+
+// configSettings returns the default workbook settings of the config.
+func configSettings() (exceref.Settings, error) {
+	if config == nil {
+		return nil, nil
+	}
+	return exceref.NewDefaultSettings(config.Settings)
 }
 
 func Execute() {
@@ -38,11 +105,69 @@ func Execute() {
 	}
 }
 
+// addWorkbookFlags adds the flags shared by the commands that load, resolve and filter workbooks.
+func addWorkbookFlags(cmd *cobra.Command) {
+	cmd.Flags().String("target", "", "Set build target such as client or server")
+	cmd.Flags().StringSlice("sheet", nil, "Limit output to the sheets matching the patterns, e.g. item_*")
+	addOpenFlags(cmd)
+}
+
+// addOpenFlags adds the flags read by getOpenOption.
+func addOpenFlags(cmd *cobra.Command) {
+	cmd.Flags().String("timezone", "", "Set timezone of time values without an offset, e.g. Asia/Tokyo")
+	cmd.Flags().Bool("evaluate-formulas", false, "Recalculate formula cells instead of reading cached results")
+}
+
+func getOpenOption(cmd *cobra.Command) (exceref.OpenOption, error) {
+	location, err := getLocation(cmd, "timezone")
+	if err != nil {
+		return exceref.OpenOption{}, errs.Wrap(err, "get timezone flag")
+	}
+	evaluateFormulas, err := cmd.Flags().GetBool("evaluate-formulas")
+	if err != nil {
+		return exceref.OpenOption{}, errs.Wrap(err, "get evaluate-formulas flag")
+	}
+	settings, err := configSettings()
+	if err != nil {
+		return exceref.OpenOption{}, errs.Wrap(err, "get config settings")
+	}
+	return exceref.OpenOption{Location: location, EvaluateFormulas: evaluateFormulas, Settings: settings}, nil
+}
+
+func getSheetFilter(cmd *cobra.Command) (exceref.SheetFilter, error) {
+	target, err := cmd.Flags().GetString("target")
+	if err != nil {
+		return exceref.SheetFilter{}, errs.Wrap(err, "get target flag")
+	}
+	sheets, err := cmd.Flags().GetStringSlice("sheet")
+	if err != nil {
+		return exceref.SheetFilter{}, errs.Wrap(err, "get sheet flag")
+	}
+	return exceref.SheetFilter{Target: target, Sheets: sheets}, nil
+}
+
+// getLocation loads the timezone named by the flag. It returns nil if the flag is empty.
+func getLocation(cmd *cobra.Command, name string) (*time.Location, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	return time.LoadLocation(value)
+}
+
 func printErrorChain(err error) {
 	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 
 	cause := errors.Unwrap(err)
 	for cause != nil {
+		if _, ok := cause.(interface{ Unwrap() []error }); ok {
+			causes := errs.Flatten(cause)
+			fmt.Fprintf(os.Stderr, "Caused by: %d errors\n", len(causes))
+			for _, e := range causes {
+				fmt.Fprintf(os.Stderr, "  %s\n", e)
+			}
+			return
+		}
 		fmt.Fprintf(os.Stderr, "Caused by: %s\n", cause)
 		cause = errors.Unwrap(cause)
 	}
@@ -6,9 +6,12 @@ import (
 	"os"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 
+	"github.com/daichirata/exceref/cmd/meta"
 	apperrs "github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
 )
 
 func TestPrintErrorChain(t *testing.T) {
@@ -37,3 +40,76 @@ func TestPrintErrorChain(t *testing.T) {
 	require.Contains(t, s, "Caused by: inner op (")
 	require.Contains(t, s, "Caused by: base failure")
 }
+
+// Not parallel: os.Stderr is swapped while printing.
+func TestPrintErrorChain_Joined(t *testing.T) {
+	err := apperrs.Wrap(errors.Join(
+		&apperrs.CellError{File: "Book1.xlsx", Sheet: "items", Cell: "B4", Err: errors.New("column:price invalid int")},
+		&apperrs.CellError{File: "Book1.xlsx", Sheet: "items", Cell: "C5", Err: errors.New("column:item reference:x value not found")},
+	), "load export target sheets")
+
+	prevStderr := os.Stderr
+	r, w, pipeErr := os.Pipe()
+	require.NoError(t, pipeErr)
+	os.Stderr = w
+	t.Cleanup(func() {
+		os.Stderr = prevStderr
+		_ = r.Close()
+	})
+
+	printErrorChain(err)
+
+	require.NoError(t, w.Close())
+	out, readErr := io.ReadAll(r)
+	require.NoError(t, readErr)
+
+	s := string(out)
+	require.Contains(t, s, "Error: load export target sheets (")
+	require.Contains(t, s, "Caused by: 2 errors\n")
+	require.Contains(t, s, "  Book1.xlsx:items!B4: column:price invalid int\n")
+	require.Contains(t, s, "  Book1.xlsx:items!C5: column:item reference:x value not found\n")
+}
+
+func TestApplyConfigFlags(t *testing.T) {
+	t.Parallel()
+
+	root := &cobra.Command{Use: "exceref"}
+	cmd := &cobra.Command{Use: "export"}
+	root.AddCommand(cmd)
+	addExportFlags(cmd)
+	require.NoError(t, cmd.Flags().Set("prefix", "cli_"))
+
+	require.NoError(t, applyConfigFlags(cmd, &exceref.Config{
+		Timezone: "Asia/Tokyo",
+		Flags: map[string]map[string]string{
+			"export": {"format": "json", "prefix": "config_", "timezone": "UTC"},
+		},
+	}))
+	for flag, want := range map[string]string{"format": "json", "prefix": "cli_", "timezone": "UTC"} {
+		got, err := cmd.Flags().GetString(flag)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	require.Error(t, applyConfigFlags(cmd, &exceref.Config{
+		Flags: map[string]map[string]string{"export": {"lang": "go"}},
+	}))
+}
+
+// Not parallel: the project config is shared by the commands.
+func TestMetaOpenOption(t *testing.T) {
+	prevConfig := config
+	config = &exceref.Config{Settings: map[string]string{"body_row": "5"}}
+	t.Cleanup(func() {
+		config = prevConfig
+	})
+
+	cmd, _, err := rootCmd.Find([]string{"meta", "export"})
+	require.NoError(t, err)
+	require.NotNil(t, cmd.Flags().Lookup("timezone"))
+	require.NotNil(t, cmd.Flags().Lookup("evaluate-formulas"))
+
+	option, err := meta.OpenOption(cmd)
+	require.NoError(t, err)
+	require.Equal(t, "5", option.Settings.Get("", "body_row"))
+}
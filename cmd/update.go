@@ -23,7 +23,12 @@ func updateFunc(cmd *cobra.Command, args []string) error {
 		return errors.New("FILENAME needs to be provided")
 	}
 
-	file, err := exceref.Open(args[0])
+	settings, err := configSettings()
+	if err != nil {
+		return errs.Wrap(err, "get config settings")
+	}
+
+	file, err := exceref.Open(args[0], exceref.OpenOption{Settings: settings})
 	if err != nil {
 		return errs.Wrap(err, "open file")
 	}
@@ -1,6 +1,7 @@
 package errs
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime"
@@ -37,3 +38,77 @@ func Wrap(err error, op string) error {
 		Err:  err,
 	}
 }
+
+// CellError is a problem located at a cell of a workbook.
+// Cell is an A1 style address and may be empty for problems of a whole sheet.
+type CellError struct {
+	File  string
+	Sheet string
+	Cell  string
+	Err   error
+}
+
+func (e *CellError) Error() string {
+	location := e.Sheet
+	if e.Cell != "" {
+		location += "!" + e.Cell
+	}
+	if e.File != "" {
+		location = e.File + ":" + location
+	}
+	return fmt.Sprintf("%s: %s", location, e.Err)
+}
+
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// Flatten expands the errors joined by errors.Join into a flat list.
+// A CellError is kept as is, and any other chain is reduced to its root cause.
+// A CellError joined more than once, such as a failure of a sheet embedded by several sheets, is listed once.
+func Flatten(err error) []error {
+	var flattened []error
+	seen := make(map[*CellError]bool)
+	for _, e := range flatten(err) {
+		if cellErr, ok := e.(*CellError); ok {
+			if seen[cellErr] {
+				continue
+			}
+			seen[cellErr] = true
+		}
+		flattened = append(flattened, e)
+	}
+	return flattened
+}
+
+func flatten(err error) []error {
+	if err == nil {
+		return nil
+	}
+	cause := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(*CellError); ok {
+			return []error{e}
+		}
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			var flattened []error
+			for _, inner := range joined.Unwrap() {
+				flattened = append(flattened, flatten(inner)...)
+			}
+			return flattened
+		}
+		cause = e
+	}
+	return []error{cause}
+}
+
+// WithFile sets the file of every CellError in err that has none.
+func WithFile(err error, file string) error {
+	for _, e := range Flatten(err) {
+		var cellErr *CellError
+		if errors.As(e, &cellErr) && cellErr.File == "" {
+			cellErr.File = file
+		}
+	}
+	return err
+}
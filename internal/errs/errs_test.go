@@ -30,3 +30,38 @@ func TestWrap_WithCallerAndUnwrap(t *testing.T) {
 	require.Equal(t, base, errors.Unwrap(err))
 	require.True(t, strings.Contains(err.Error(), "test op (errs_test.go:"))
 }
+
+func TestCellError_Error(t *testing.T) {
+	t.Parallel()
+
+	base := errors.New("invalid value")
+	require.Equal(t, "Book1.xlsx:items!B5: invalid value", (&errs.CellError{File: "Book1.xlsx", Sheet: "items", Cell: "B5", Err: base}).Error())
+	require.Equal(t, "items!B5: invalid value", (&errs.CellError{Sheet: "items", Cell: "B5", Err: base}).Error())
+	require.Equal(t, "items: invalid value", (&errs.CellError{Sheet: "items", Err: base}).Error())
+	require.Equal(t, base, errors.Unwrap(&errs.CellError{Err: base}))
+}
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, errs.Flatten(nil))
+
+	a := &errs.CellError{Sheet: "items", Cell: "A4", Err: errors.New("a")}
+	b := &errs.CellError{Sheet: "items", Cell: "B5", Err: errors.New("b")}
+	c := errors.New("c")
+	err := errs.Wrap(errors.Join(errs.Wrap(errors.Join(a, b), "inner op"), errs.Wrap(c, "other op")), "outer op")
+	require.Equal(t, []error{a, b, c}, errs.Flatten(err))
+	require.Equal(t, []error{a, b}, errs.Flatten(errors.Join(a, errors.Join(b, a))))
+}
+
+func TestWithFile(t *testing.T) {
+	t.Parallel()
+
+	a := &errs.CellError{Sheet: "items", Cell: "A4", Err: errors.New("a")}
+	b := &errs.CellError{File: "Master.xlsx", Sheet: "master", Cell: "A6", Err: errors.New("b")}
+	err := errs.WithFile(errs.Wrap(errors.Join(a, b), "op"), "Book1.xlsx")
+	require.Error(t, err)
+	require.Equal(t, "Book1.xlsx", a.File)
+	require.Equal(t, "Master.xlsx", b.File)
+	require.NoError(t, errs.WithFile(nil, "Book1.xlsx"))
+}
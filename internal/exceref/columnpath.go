@@ -0,0 +1,134 @@
+package exceref
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var columnPathSegmentRegexp = regexp.MustCompile(`^([^\[\]]+)(?:\[(\d+)\])?$`)
+
+// columnPathSegment is a part of a dotted column name such as reward.item_id or drops[0].id.
+// Index is -1 unless the segment addresses an array element.
+type columnPathSegment struct {
+	Key   string
+	Index int
+}
+
+func parseColumnPath(name string) ([]columnPathSegment, error) {
+	parts := strings.Split(name, ".")
+	path := make([]columnPathSegment, len(parts))
+	for i, part := range parts {
+		m := columnPathSegmentRegexp.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid column name: %s", name)
+		}
+		path[i] = columnPathSegment{Key: m[1], Index: -1}
+		if m[2] != "" {
+			index, err := strconv.Atoi(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid column name: %s", name)
+			}
+			path[i].Index = index
+		}
+	}
+	return path, nil
+}
+
+// setColumnPath stores the value into data following the path, creating nested maps and slices on the way.
+func setColumnPath(data map[string]any, path []columnPathSegment, value any) {
+	segment := path[0]
+	last := len(path) == 1
+
+	if segment.Index < 0 {
+		if last {
+			data[segment.Key] = value
+			return
+		}
+		child, ok := data[segment.Key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			data[segment.Key] = child
+		}
+		setColumnPath(child, path[1:], value)
+		return
+	}
+
+	list, _ := data[segment.Key].([]any)
+	for len(list) <= segment.Index {
+		list = append(list, nil)
+	}
+	if last {
+		list[segment.Index] = value
+	} else {
+		child, ok := list[segment.Index].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			list[segment.Index] = child
+		}
+		setColumnPath(child, path[1:], value)
+	}
+	data[segment.Key] = list
+}
+
+// columnNode is a tree of dotted column names, used to validate them and to build nested types.
+type columnNode struct {
+	Key      string
+	List     bool
+	Column   *Column
+	Children []*columnNode
+}
+
+func (n *columnNode) child(key string) *columnNode {
+	for _, c := range n.Children {
+		if c.Key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+func newColumnTree(columns []*Column) (*columnNode, error) {
+	root := &columnNode{}
+	seen := make(map[string]bool)
+	for _, column := range columns {
+		if !column.IsExportable() {
+			continue
+		}
+		if seen[column.Name] {
+			return nil, fmt.Errorf("column:%s duplicated", column.Name)
+		}
+		seen[column.Name] = true
+
+		path, err := parseColumnPath(column.Name)
+		if err != nil {
+			return nil, err
+		}
+		node := root
+		for i, segment := range path {
+			if node.Column != nil {
+				return nil, fmt.Errorf("column:%s conflicts with column:%s", column.Name, node.Column.Name)
+			}
+			child := node.child(segment.Key)
+			if child == nil {
+				child = &columnNode{Key: segment.Key, List: segment.Index >= 0}
+				node.Children = append(node.Children, child)
+			} else if child.List != (segment.Index >= 0) {
+				return nil, fmt.Errorf("column:%s mixes object and array for %s", column.Name, segment.Key)
+			}
+			node = child
+
+			if i == len(path)-1 {
+				if len(node.Children) > 0 {
+					return nil, fmt.Errorf("column:%s conflicts with another column", column.Name)
+				}
+				// Array elements share a node, e.g. drops[0].id and drops[1].id, so the first column wins.
+				if node.Column == nil {
+					node.Column = column
+				}
+			}
+		}
+	}
+	return root, nil
+}
@@ -0,0 +1,169 @@
+package exceref
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the project config looked up in the working directory.
+const ConfigFileName = "exceref.yaml"
+
+// Config is a project config, which declares the workbooks and the outputs built from them.
+// Relative paths are resolved against the directory of the config file.
+type Config struct {
+	// Workbooks are directories or glob patterns of the workbooks.
+	Workbooks []string `yaml:"workbooks"`
+	// Timezone is the timezone of time values without an offset, unless a workbook sets one.
+	Timezone         string `yaml:"timezone"`
+	EvaluateFormulas bool   `yaml:"evaluate_formulas"`
+	// Settings are the defaults of the _settings sheets, such as type_row or body_row.
+	Settings map[string]string `yaml:"settings"`
+	// Update runs update on every workbook before the outputs are built.
+	Update    bool             `yaml:"update"`
+	Exports   []ExportConfig   `yaml:"exports"`
+	Generates []GenerateConfig `yaml:"generates"`
+	// Flags are the flag defaults of the commands, keyed by the command such as export or project generate.
+	Flags map[string]map[string]string `yaml:"flags"`
+}
+
+type ExportConfig struct {
+	Format         string   `yaml:"format"`
+	Out            string   `yaml:"out"`
+	Prefix         string   `yaml:"prefix"`
+	ListSeparator  string   `yaml:"list_separator"`
+	Keyed          bool     `yaml:"keyed"`
+	OutputTimezone string   `yaml:"output_timezone"`
+	Target         string   `yaml:"target"`
+	Sheets         []string `yaml:"sheets"`
+}
+
+type GenerateConfig struct {
+	Lang     string   `yaml:"lang"`
+	Template string   `yaml:"template"`
+	Out      string   `yaml:"out"`
+	Prefix   string   `yaml:"prefix"`
+	Package  string   `yaml:"package"`
+	Target   string   `yaml:"target"`
+	Sheets   []string `yaml:"sheets"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrap(err, "read config")
+	}
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errs.Wrap(err, "decode config")
+	}
+
+	dir := filepath.Dir(path)
+	for i := range config.Workbooks {
+		config.Workbooks[i] = configPath(dir, config.Workbooks[i])
+	}
+	for i := range config.Exports {
+		if config.Exports[i].Out == "" {
+			return nil, fmt.Errorf("%s: exports[%d]: out is required", filepath.Base(path), i)
+		}
+		if config.Exports[i].Format == "" {
+			config.Exports[i].Format = "csv"
+		}
+		if !lo.Contains(ExportFormats, config.Exports[i].Format) {
+			return nil, fmt.Errorf("%s: exports[%d]: unknown format: %s", filepath.Base(path), i, config.Exports[i].Format)
+		}
+		config.Exports[i].Out = configPath(dir, config.Exports[i].Out)
+	}
+	for i := range config.Generates {
+		if config.Generates[i].Out == "" || config.Generates[i].Template == "" {
+			return nil, fmt.Errorf("%s: generates[%d]: out and template are required", filepath.Base(path), i)
+		}
+		if config.Generates[i].Lang == "" {
+			config.Generates[i].Lang = "go"
+		}
+		if !lo.Contains(GenerateLangs, config.Generates[i].Lang) {
+			return nil, fmt.Errorf("%s: generates[%d]: unknown lang: %s", filepath.Base(path), i, config.Generates[i].Lang)
+		}
+		config.Generates[i].Out = configPath(dir, config.Generates[i].Out)
+		config.Generates[i].Template = configPath(dir, config.Generates[i].Template)
+	}
+	for _, flags := range config.Flags {
+		for _, key := range configPathFlags {
+			if value := flags[key]; value != "" {
+				flags[key] = configPath(dir, value)
+			}
+		}
+	}
+	return config, nil
+}
+
+// configPathFlags are the flags taking a path, which are resolved against the config directory as well.
+var configPathFlags = []string{"out", "template"}
+
+func configPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (c *Config) OpenOption() (OpenOption, error) {
+	option := OpenOption{EvaluateFormulas: c.EvaluateFormulas}
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return option, errs.Wrap(err, "load timezone")
+		}
+		option.Location = loc
+	}
+	settings, err := NewDefaultSettings(c.Settings)
+	if err != nil {
+		return option, errs.Wrap(err, "build settings")
+	}
+	option.Settings = settings
+	return option, nil
+}
+
+func (c ExportConfig) ExportOption() (ExportOption, error) {
+	option := ExportOption{
+		Prefix:          c.Prefix,
+		OutDir:          c.Out,
+		ListSeparator:   c.ListSeparator,
+		KeyByPrimaryKey: c.Keyed,
+	}
+	if c.OutputTimezone != "" {
+		loc, err := time.LoadLocation(c.OutputTimezone)
+		if err != nil {
+			return option, errs.Wrap(err, "load output timezone")
+		}
+		option.Location = loc
+	}
+	return option, nil
+}
+
+func (c ExportConfig) SheetFilter() SheetFilter {
+	return SheetFilter{Target: c.Target, Sheets: c.Sheets}
+}
+
+func (c GenerateConfig) GenerateOption() GenerateOption {
+	return GenerateOption{
+		Prefix:       c.Prefix,
+		OutDir:       c.Out,
+		TemplatePath: c.Template,
+		Package:      c.Package,
+	}
+}
+
+func (c GenerateConfig) SheetFilter() SheetFilter {
+	return SheetFilter{Target: c.Target, Sheets: c.Sheets}
+}
@@ -0,0 +1,84 @@
+package exceref_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daichirata/exceref/internal/exceref"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, exceref.ConfigFileName)
+	require.NoError(t, os.WriteFile(path, []byte(`
+workbooks: [books]
+timezone: Asia/Tokyo
+settings:
+  body_row: "5"
+exports:
+  - format: json
+    out: out/json
+    sheets: [item_*]
+    target: client
+generates:
+  - template: templates/go.tmpl
+    out: /tmp/gen
+flags:
+  export:
+    format: yaml
+    out: out/yaml
+  generate:
+    template: /tmp/go.tmpl
+`), 0o644))
+
+	config, err := exceref.LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "books")}, config.Workbooks)
+	require.Equal(t, filepath.Join(dir, "out/json"), config.Exports[0].Out)
+	require.Equal(t, exceref.SheetFilter{Target: "client", Sheets: []string{"item_*"}}, config.Exports[0].SheetFilter())
+	require.Equal(t, "go", config.Generates[0].Lang)
+	require.Equal(t, filepath.Join(dir, "templates/go.tmpl"), config.Generates[0].Template)
+	require.Equal(t, "/tmp/gen", config.Generates[0].Out)
+	require.Equal(t, map[string]map[string]string{
+		"export":   {"format": "yaml", "out": filepath.Join(dir, "out/yaml")},
+		"generate": {"template": "/tmp/go.tmpl"},
+	}, config.Flags)
+
+	option, err := config.OpenOption()
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Tokyo", option.Location.String())
+	require.Equal(t, exceref.Settings{{Key: "body_row", Value: "5"}}, option.Settings)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		"workbook: [books]\n",
+		"exports:\n  - format: json\n",
+		"generates:\n  - out: gen\n",
+	} {
+		path := filepath.Join(t.TempDir(), exceref.ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+		_, err := exceref.LoadConfig(path)
+		require.Error(t, err, body)
+	}
+
+	for body, message := range map[string]string{
+		"exports:\n  - format: jsno\n    out: out\n":                  "exceref.yaml: exports[0]: unknown format: jsno",
+		"generates:\n  - lang: rust\n    out: gen\n    template: t\n": "exceref.yaml: generates[0]: unknown lang: rust",
+	} {
+		path := filepath.Join(t.TempDir(), exceref.ConfigFileName)
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+		_, err := exceref.LoadConfig(path)
+		require.EqualError(t, err, message)
+	}
+
+	config := &exceref.Config{Settings: map[string]string{"header_row": "1"}}
+	_, err := config.OpenOption()
+	require.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package exceref
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samber/lo"
+)
+
+const ConstraintDefinitionSheetName = "_constraints"
+
+// Constraint is a rule for the values of a column, defined by a row of the _constraints sheet.
+type Constraint struct {
+	Sheet      string
+	Column     string
+	PrimaryKey bool
+	Required   bool
+	Unique     bool
+	Min        *float64
+	Max        *float64
+	Regex      *regexp.Regexp
+	MaxLength  int
+	Allowed    []string
+}
+
+func NewConstraints(sheet *Sheet) ([]*Constraint, error) {
+	var constraints []*Constraint
+
+	for i, row := range sheet.Rows {
+		constraint := &Constraint{}
+		filled := false
+		for _, cell := range row {
+			if cell.Raw == "" {
+				continue
+			}
+			filled = true
+			var err error
+			switch cell.Column.Name {
+			case "sheet":
+				constraint.Sheet = cell.Raw
+			case "column":
+				constraint.Column = cell.Raw
+			case "primary_key":
+				constraint.PrimaryKey, err = strconv.ParseBool(cell.Raw)
+			case "required":
+				constraint.Required, err = strconv.ParseBool(cell.Raw)
+			case "unique":
+				constraint.Unique, err = strconv.ParseBool(cell.Raw)
+			case "min":
+				constraint.Min, err = parseConstraintFloat(cell.Raw)
+			case "max":
+				constraint.Max, err = parseConstraintFloat(cell.Raw)
+			case "regex":
+				constraint.Regex, err = regexp.Compile(cell.Raw)
+			case "max_length":
+				constraint.MaxLength, err = strconv.Atoi(cell.Raw)
+			case "allowed":
+				constraint.Allowed = lo.Map(strings.Split(cell.Raw, ","), func(s string, _ int) string {
+					return strings.TrimSpace(s)
+				})
+			default:
+				return nil, sheet.CellError(0, nil, fmt.Errorf("unknown column: %s", cell.Column.Name))
+			}
+			if err != nil {
+				return nil, sheet.CellError(sheet.RowNumber(i), cell.Column,
+					fmt.Errorf("column:%s invalid value:%s: %w", cell.Column.Name, cell.Raw, err))
+			}
+		}
+		if !filled {
+			continue
+		}
+		if constraint.Sheet == "" || constraint.Column == "" {
+			return nil, sheet.CellError(sheet.RowNumber(i), nil, errors.New("sheet and column are required"))
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints, nil
+}
+
+func parseConstraintFloat(s string) (*float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate checks every cell of the column and returns an error for each failing cell.
+func (c *Constraint) Validate(sheet *Sheet) []error {
+	column, err := sheet.Column(c.Column)
+	if err != nil {
+		return []error{sheet.CellError(0, nil, err)}
+	}
+
+	var failures []error
+	seen := make(map[string]int)
+	for i, row := range sheet.Rows {
+		cell := row[column.Index]
+		rowNumber := sheet.RowNumber(i)
+		fail := func(format string, args ...any) {
+			failures = append(failures, sheet.CellError(rowNumber, column, fmt.Errorf("column:%s value:%s %s",
+				column.Name, cell.Raw, fmt.Sprintf(format, args...))))
+		}
+
+		if cell.Raw == "" {
+			if c.Required {
+				fail("is required")
+			}
+			continue
+		}
+		if c.Unique {
+			if first, ok := seen[cell.Raw]; ok {
+				fail("is duplicated with row:%d", first)
+			} else {
+				seen[cell.Raw] = rowNumber
+			}
+		}
+
+		values := []any{cell.Value}
+		raws := []string{cell.Raw}
+		if list, ok := cell.Value.([]any); ok {
+			values = list
+			raws = lo.Map(strings.Split(cell.Raw, column.Type.Separator()), func(s string, _ int) string {
+				return strings.TrimSpace(s)
+			})
+		}
+		if c.Min != nil || c.Max != nil {
+			for _, value := range values {
+				if value == nil {
+					continue
+				}
+				f, ok := constraintNumber(value)
+				switch {
+				case !ok:
+					fail("is not a number")
+				case c.Min != nil && f < *c.Min:
+					fail("is less than min:%v", *c.Min)
+				case c.Max != nil && f > *c.Max:
+					fail("is greater than max:%v", *c.Max)
+				}
+			}
+		}
+		// regex, max_length and allowed check the values as written, e.g. a datetime or a float as it reads
+		// in the workbook rather than as it is parsed.
+		for _, s := range raws {
+			if c.Regex != nil && !c.Regex.MatchString(s) {
+				fail("does not match regex:%s", c.Regex)
+			}
+			if c.MaxLength > 0 && utf8.RuneCountInString(s) > c.MaxLength {
+				fail("is longer than max_length:%d", c.MaxLength)
+			}
+			if len(c.Allowed) > 0 && !lo.Contains(c.Allowed, s) {
+				fail("is not allowed: %s", strings.Join(c.Allowed, ","))
+			}
+		}
+	}
+	return failures
+}
+
+func constraintNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
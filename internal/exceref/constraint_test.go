@@ -0,0 +1,112 @@
+package exceref_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+func TestNewConstraints(t *testing.T) {
+	rows := [][]string{
+		{"sheet", "column", "required", "unique", "min", "max", "regex", "max_length", "allowed"},
+		{"items", "id", "true", "TRUE", "", "", "^item_", "", ""},
+		{"items", "price", "", "", "0", "100.5", "", "", ""},
+		{"items", "kind", "", "", "", "", "", "5", "a, b"},
+		{"", "", "", "", "", "", "", "", ""},
+	}
+	constraints, err := exceref.NewConstraints(exceref.NewConstraintDefinitionSheet("_constraints", rows))
+	require.NoError(t, err)
+	require.Len(t, constraints, 3)
+	require.True(t, constraints[0].Required)
+	require.True(t, constraints[0].Unique)
+	require.Equal(t, "^item_", constraints[0].Regex.String())
+	require.Equal(t, 0.0, *constraints[1].Min)
+	require.Equal(t, 100.5, *constraints[1].Max)
+	require.Equal(t, 5, constraints[2].MaxLength)
+	require.Equal(t, []string{"a", "b"}, constraints[2].Allowed)
+
+	_, err = exceref.NewConstraints(exceref.NewConstraintDefinitionSheet("_constraints", [][]string{
+		{"sheet", "column", "min"},
+		{"items", "price", "x"},
+	}))
+	require.EqualError(t, err, `_constraints!C2: column:min invalid value:x: strconv.ParseFloat: parsing "x": invalid syntax`)
+
+	for _, row := range [][]string{{"items", "", "true"}, {"", "price", "true"}, {"", "", "true"}} {
+		_, err = exceref.NewConstraints(exceref.NewConstraintDefinitionSheet("_constraints", [][]string{
+			{"sheet", "column", "required"},
+			{"items", "id", "true"},
+			row,
+		}))
+		require.EqualError(t, err, "_constraints!3: sheet and column are required")
+	}
+}
+
+func TestConstraint_Validate(t *testing.T) {
+	rows := [][]string{
+		{"string", "int", "[]int", "string"},
+		{"id", "price", "rates", "kind"},
+		{},
+		{"item_a", "10", "1,2", "a"},
+		{"item_a", "-1", "1,200", "abcdef"},
+		{"", "10", "", "c"},
+	}
+	sheet, err := exceref.NewDataSeet("items", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	rules, err := exceref.NewConstraints(exceref.NewConstraintDefinitionSheet("_constraints", [][]string{
+		{"sheet", "column", "required", "unique", "min", "max", "max_length", "allowed"},
+		{"items", "id", "true", "true", "", "", "", ""},
+		{"items", "price", "", "", "0", "", "", ""},
+		{"items", "rates", "", "", "", "100", "", ""},
+		{"items", "kind", "", "", "", "", "3", "a,b,abcdef"},
+	}))
+	require.NoError(t, err)
+
+	var messages []string
+	for _, rule := range rules {
+		for _, err := range rule.Validate(sheet) {
+			messages = append(messages, err.Error())
+		}
+	}
+	require.Equal(t, []string{
+		"items!A5: column:id value:item_a is duplicated with row:4",
+		"items!A6: column:id value: is required",
+		"items!B5: column:price value:-1 is less than min:0",
+		"items!C5: column:rates value:1,200 is greater than max:100",
+		"items!D5: column:kind value:abcdef is longer than max_length:3",
+		"items!D6: column:kind value:c is not allowed: a,b,abcdef",
+	}, messages)
+}
+
+func TestConstraint_Validate_Raw(t *testing.T) {
+	sheet, err := exceref.NewDataSeet("events", [][]string{
+		{"datetime", "float", "[]float"},
+		{"start_at", "rate", "rates"},
+		{},
+		{"2024-01-02 03:04:05", "1.50", "0.10, 2.00"},
+		{"2024-01-02", "2", "1.5"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	rules, err := exceref.NewConstraints(exceref.NewConstraintDefinitionSheet("_constraints", [][]string{
+		{"sheet", "column", "regex", "max_length", "allowed"},
+		{"events", "start_at", `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`, "", ""},
+		{"events", "rate", "", "", "1.50,2.00"},
+		{"events", "rates", `^\d+\.\d{2}$`, "4", ""},
+	}))
+	require.NoError(t, err)
+
+	var messages []string
+	for _, rule := range rules {
+		for _, err := range rule.Validate(sheet) {
+			messages = append(messages, err.Error())
+		}
+	}
+	require.Equal(t, []string{
+		`events!A5: column:start_at value:2024-01-02 does not match regex:^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`,
+		"events!B5: column:rate value:2 is not allowed: 1.50,2.00",
+		`events!C5: column:rates value:1.5 does not match regex:^\d+\.\d{2}$`,
+	}, messages)
+}
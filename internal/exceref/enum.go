@@ -0,0 +1,82 @@
+package exceref
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const TypeDefinitionSheetName = "_types"
+
+// Enum is a list of allowed values defined by a column of the _types sheet.
+type Enum struct {
+	Name     string
+	Index    int
+	Members  []string
+	FirstRow int
+	Size     int
+}
+
+func (e *Enum) Contains(value string) bool {
+	if e == nil {
+		return false
+	}
+	for _, member := range e.Members {
+		if member == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Sqref returns the absolute range of the enum members in the _types sheet.
+func (e *Enum) Sqref() (string, error) {
+	first, err := excelize.CoordinatesToCellName(e.Index+1, e.FirstRow, true)
+	if err != nil {
+		return "", err
+	}
+	last, err := excelize.CoordinatesToCellName(e.Index+1, e.FirstRow+max(e.Size, 1)-1, true)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s", first, last), nil
+}
+
+// NewEnums builds an enum of each column of the _types sheet, skipping comment columns. Comment rows are rejected
+// between the members, since the drop-down lists of update take the members from the rows of the sheet.
+func NewEnums(sheet *Sheet) ([]*Enum, error) {
+	firstRow := sheet.Layout.WithDefaults().BodyRow
+	for i, rowNumber := range sheet.RowNumbers {
+		if rowNumber != firstRow+i {
+			return nil, sheet.CellError(firstRow+i, nil, errors.New("comment rows cannot be placed between enum members"))
+		}
+	}
+
+	var enums []*Enum
+	for _, column := range sheet.Columns {
+		if column.Name == "" || column.comment {
+			continue
+		}
+		enum := &Enum{
+			Name:     column.Name,
+			Index:    column.Index,
+			FirstRow: firstRow,
+		}
+		if len(sheet.Rows) > 0 {
+			enum.Size = sheet.RowNumber(len(sheet.Rows)-1) - enum.FirstRow + 1
+		}
+		for _, row := range sheet.Rows {
+			member := row[column.Index].Raw
+			if member == "" {
+				continue
+			}
+			if enum.Contains(member) {
+				return nil, fmt.Errorf("enum:%s duplicate member:%s", enum.Name, member)
+			}
+			enum.Members = append(enum.Members, member)
+		}
+		enums = append(enums, enum)
+	}
+	return enums, nil
+}
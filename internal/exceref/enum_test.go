@@ -0,0 +1,46 @@
+package exceref_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+func TestNewEnums(t *testing.T) {
+	sheet, err := exceref.NewDataSeet(exceref.TypeDefinitionSheetName, [][]string{
+		{"string", "", "string"},
+		{"element", "#memo", "rarity"},
+		{},
+		{"fire", "hot", "common"},
+		{"water", "", "rare"},
+		{"#wind", "", ""},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	enums, err := exceref.NewEnums(sheet)
+	require.NoError(t, err)
+	require.Len(t, enums, 2)
+	require.Equal(t, "element", enums[0].Name)
+	require.Equal(t, []string{"fire", "water"}, enums[0].Members)
+	require.Equal(t, "rarity", enums[1].Name)
+	sqref, err := enums[1].Sqref()
+	require.NoError(t, err)
+	require.Equal(t, "$C$4:$C$5", sqref)
+}
+
+func TestNewEnums_CommentRow(t *testing.T) {
+	sheet, err := exceref.NewDataSeet(exceref.TypeDefinitionSheetName, [][]string{
+		{"string"},
+		{"element"},
+		{},
+		{"fire"},
+		{"# deprecated"},
+		{"water"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	_, err = exceref.NewEnums(sheet)
+	require.EqualError(t, err, "_types!5: comment rows cannot be placed between enum members")
+}
@@ -6,41 +6,61 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/daichirata/exceref/internal/errs"
 	"gopkg.in/yaml.v3"
 )
 
+type ExportOption struct {
+	Prefix string
+	OutDir string
+	// ListSeparator joins list values in csv output. The separator of the column type is used if empty.
+	ListSeparator string
+	// KeyByPrimaryKey writes json and yaml as an object keyed by the primary key instead of an array.
+	KeyByPrimaryKey bool
+	// Location converts datetime values to the timezone before writing. The parsed offset is kept if nil.
+	Location *time.Location
+}
+
+func (o ExportOption) inLocation(t time.Time) time.Time {
+	if o.Location == nil {
+		return t
+	}
+	return t.In(o.Location)
+}
+
 type Exporter interface {
 	Export(sheet *Sheet) error
 }
 
-func BuildExporter(format, outDir, prefix string) Exporter {
+// ExportFormats are the formats BuildExporter builds an exporter for.
+var ExportFormats = []string{"csv", "json", "yaml"}
+
+func BuildExporter(format string, option ExportOption) Exporter {
 	switch format {
 	case "json":
-		return NewJSONExporter(outDir, prefix)
+		return NewJSONExporter(option)
 	case "yaml":
-		return NewYAMLExporter(outDir, prefix)
+		return NewYAMLExporter(option)
 	default:
-		return NewCSVExporter(outDir, prefix)
+		return NewCSVExporter(option)
 	}
 }
 
-func NewCSVExporter(outDir, prefix string) *csvExporter {
+func NewCSVExporter(option ExportOption) *csvExporter {
 	return &csvExporter{
-		prefix: prefix,
-		outDir: outDir,
+		option: option,
 	}
 }
 
 type csvExporter struct {
-	outDir string
-	prefix string
+	option ExportOption
 }
 
 func (e *csvExporter) Export(sheet *Sheet) error {
-	f, err := os.Create(filepath.Join(e.outDir, e.prefix+sheet.Name+".csv"))
+	f, err := os.Create(filepath.Join(e.option.OutDir, e.option.Prefix+sheet.Name+".csv"))
 	if err != nil {
 		return errs.Wrap(err, "create csv file")
 	}
@@ -66,7 +86,7 @@ func (e *csvExporter) Export(sheet *Sheet) error {
 	for _, row := range sheet.Rows {
 		records := make([]string, len(columns))
 		for i, column := range columns {
-			value, err := e.toCSVString(row[column.Index].Value)
+			value, err := e.toCSVString(row[column.Index].Value, e.listSeparator(column))
 			if err != nil {
 				return errs.Wrap(err, "format csv value")
 			}
@@ -81,56 +101,118 @@ func (e *csvExporter) Export(sheet *Sheet) error {
 	return nil
 }
 
-func (e *csvExporter) toCSVString(value any) (string, error) {
+func (e *csvExporter) listSeparator(column *Column) string {
+	if e.option.ListSeparator != "" {
+		return e.option.ListSeparator
+	}
+	return column.Type.Separator()
+}
+
+func (e *csvExporter) toCSVString(value any, separator string) (string, error) {
 	switch t := value.(type) {
+	case nil:
+		return "", nil
 	case string, int, int64, float64, bool:
 		return fmt.Sprint(t), nil
 	case time.Time:
-		return t.Format(time.RFC3339), nil
+		return e.option.inLocation(t).Format(time.RFC3339), nil
+	case map[string]any:
+		b, err := json.Marshal(convertTime(t, e.option))
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case []any:
+		values := make([]string, len(t))
+		for i, v := range t {
+			s, err := e.toCSVString(v, separator)
+			if err != nil {
+				return "", err
+			}
+			values[i] = s
+		}
+		return strings.Join(values, separator), nil
 	}
 	return "", fmt.Errorf("unmatched type:%#v", value)
 }
 
-func NewJSONExporter(outDir, prefix string) *jsonExporter {
+func NewJSONExporter(option ExportOption) *jsonExporter {
 	return &jsonExporter{
-		outDir: outDir,
-		prefix: prefix,
+		option: option,
 	}
 }
 
 type jsonExporter struct {
-	outDir string
-	prefix string
+	option ExportOption
 }
 
 func (e *jsonExporter) Export(sheet *Sheet) error {
-	f, err := os.Create(filepath.Join(e.outDir, e.prefix+sheet.Name+".json"))
+	f, err := os.Create(filepath.Join(e.option.OutDir, e.option.Prefix+sheet.Name+".json"))
 	if err != nil {
 		return errs.Wrap(err, "create json file")
 	}
 	defer f.Close()
 
-	return errs.Wrap(json.NewEncoder(f).Encode(sheet.Map()), "encode json")
+	data, err := exportData(sheet, e.option)
+	if err != nil {
+		return errs.Wrap(err, "build json data")
+	}
+	return errs.Wrap(json.NewEncoder(f).Encode(data), "encode json")
 }
 
-func NewYAMLExporter(outDir, prefix string) *yamlExporter {
+func NewYAMLExporter(option ExportOption) *yamlExporter {
 	return &yamlExporter{
-		outDir: outDir,
-		prefix: prefix,
+		option: option,
 	}
 }
 
 type yamlExporter struct {
-	outDir string
-	prefix string
+	option ExportOption
 }
 
 func (e *yamlExporter) Export(sheet *Sheet) error {
-	f, err := os.Create(filepath.Join(e.outDir, e.prefix+sheet.Name+".yaml"))
+	f, err := os.Create(filepath.Join(e.option.OutDir, e.option.Prefix+sheet.Name+".yaml"))
 	if err != nil {
 		return errs.Wrap(err, "create yaml file")
 	}
 	defer f.Close()
 
-	return errs.Wrap(yaml.NewEncoder(f).Encode(sheet.Map()), "encode yaml")
+	data, err := exportData(sheet, e.option)
+	if err != nil {
+		return errs.Wrap(err, "build yaml data")
+	}
+	return errs.Wrap(yaml.NewEncoder(f).Encode(data), "encode yaml")
+}
+
+func exportData(sheet *Sheet, option ExportOption) (any, error) {
+	data := sheet.Map()
+	if option.Location != nil {
+		for _, m := range data {
+			convertTime(m, option)
+		}
+	}
+	if option.KeyByPrimaryKey {
+		return sheet.keyedMap(data)
+	}
+	return data, nil
+}
+
+// convertTime moves the time values nested in value to the output timezone.
+// Maps built by Sheet.Map are updated in place, while lists are copied since they may be shared with the cells.
+func convertTime(value any, option ExportOption) any {
+	switch v := value.(type) {
+	case time.Time:
+		return option.inLocation(v)
+	case map[string]any:
+		for key, elem := range v {
+			v[key] = convertTime(elem, option)
+		}
+	case []any:
+		list := make([]any, len(v))
+		for i, elem := range v {
+			list[i] = convertTime(elem, option)
+		}
+		return list
+	}
+	return value
 }
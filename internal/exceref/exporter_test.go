@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -38,7 +39,7 @@ func TestBuildExporter(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			exporter := BuildExporter(tc.format, t.TempDir(), "prefix_")
+			exporter := BuildExporter(tc.format, ExportOption{OutDir: t.TempDir(), Prefix: "prefix_"})
 			require.IsType(t, tc.exporterTy, exporter)
 		})
 	}
@@ -48,7 +49,7 @@ func TestCSVExporter_Export(t *testing.T) {
 	t.Parallel()
 
 	outDir := t.TempDir()
-	exporter := NewCSVExporter(outDir, "p_")
+	exporter := NewCSVExporter(ExportOption{OutDir: outDir, Prefix: "p_"})
 	columns := []*Column{
 		{Name: "id", Type: ColumnTypeInt, Index: 0},
 		{Name: "name", Type: ColumnTypeString, Index: 1},
@@ -73,3 +74,102 @@ func TestCSVExporter_Export(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "id,name\n1,Alice\n", string(body))
 }
+
+func TestCSVExporter_ExportList(t *testing.T) {
+	t.Parallel()
+
+	columns := []*Column{
+		{Name: "id", Type: ColumnTypeInt, Index: 0},
+		{Name: "tags", Type: "[]string(;)", Index: 1},
+		{Name: "items", Type: "[]int", Index: 2},
+	}
+	sheet := &Sheet{
+		Name:    "Users",
+		Columns: columns,
+		Rows: []Row{
+			{
+				{Column: columns[0], Value: 1},
+				{Column: columns[1], Value: []any{"a", "b"}},
+				{Column: columns[2], Value: []any{1, 2}},
+			},
+		},
+	}
+
+	outDir := t.TempDir()
+	require.NoError(t, NewCSVExporter(ExportOption{OutDir: outDir}).Export(sheet))
+	body, err := os.ReadFile(filepath.Join(outDir, "Users.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "id,tags,items\n1,a;b,\"1,2\"\n", string(body))
+
+	outDir = t.TempDir()
+	require.NoError(t, NewCSVExporter(ExportOption{OutDir: outDir, ListSeparator: "|"}).Export(sheet))
+	body, err = os.ReadFile(filepath.Join(outDir, "Users.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "id,tags,items\n1,a|b,1|2\n", string(body))
+}
+
+func TestJSONExporter_ExportNullable(t *testing.T) {
+	t.Parallel()
+
+	columns := []*Column{
+		{Name: "id", Type: ColumnTypeInt, Index: 0},
+		{Name: "score", Type: "int?", Index: 1},
+	}
+	sheet := &Sheet{
+		Name:    "Users",
+		Columns: columns,
+		Rows: []Row{
+			{{Column: columns[0], Value: 1}, {Column: columns[1], Value: nil}},
+			{{Column: columns[0], Value: 2}, {Column: columns[1], Value: 0}},
+		},
+	}
+
+	outDir := t.TempDir()
+	require.NoError(t, NewJSONExporter(ExportOption{OutDir: outDir}).Export(sheet))
+	body, err := os.ReadFile(filepath.Join(outDir, "Users.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"id":1,"score":null},{"id":2,"score":0}]`+"\n", string(body))
+
+	require.NoError(t, NewCSVExporter(ExportOption{OutDir: outDir}).Export(sheet))
+	body, err = os.ReadFile(filepath.Join(outDir, "Users.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "id,score\n1,\n2,0\n", string(body))
+}
+
+func TestExporter_ExportLocation(t *testing.T) {
+	t.Parallel()
+
+	columns := []*Column{
+		{Name: "id", Type: ColumnTypeInt, Index: 0},
+		{Name: "start_at", Type: ColumnTypeDatetime, Index: 1},
+		{Name: "reminds_at", Type: "[]datetime", Index: 2},
+	}
+	start := time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)
+	reminds := []any{start.Add(-time.Hour)}
+	sheet := &Sheet{
+		Name:    "Events",
+		Columns: columns,
+		Rows: []Row{
+			{{Column: columns[0], Value: 1}, {Column: columns[1], Value: start}, {Column: columns[2], Value: reminds}},
+		},
+	}
+	option := ExportOption{Location: time.FixedZone("JST", 9*60*60)}
+
+	outDir := t.TempDir()
+	option.OutDir = outDir
+	require.NoError(t, NewJSONExporter(option).Export(sheet))
+	body, err := os.ReadFile(filepath.Join(outDir, "Events.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"id":1,"reminds_at":["2024-01-02T09:00:00+09:00"],"start_at":"2024-01-02T10:00:00+09:00"}]`+"\n", string(body))
+	require.Equal(t, time.UTC, reminds[0].(time.Time).Location())
+
+	require.NoError(t, NewYAMLExporter(option).Export(sheet))
+	body, err = os.ReadFile(filepath.Join(outDir, "Events.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(body), "start_at: 2024-01-02T10:00:00+09:00")
+
+	require.NoError(t, NewCSVExporter(option).Export(sheet))
+	body, err = os.ReadFile(filepath.Join(outDir, "Events.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "id,start_at,reminds_at\n1,2024-01-02T10:00:00+09:00,2024-01-02T09:00:00+09:00\n", string(body))
+}
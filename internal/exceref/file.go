@@ -1,12 +1,17 @@
 package exceref
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -15,23 +20,38 @@ const (
 	ReferenceDataSheetName       = "_reference_data"
 )
 
-func Open(path string) (*File, error) {
+type OpenOption struct {
+	// Location is the timezone of time values without an offset, unless the workbook sets one in _settings.
+	Location *time.Location
+	// EvaluateFormulas recalculates formula cells of data sheets instead of reading their cached results.
+	EvaluateFormulas bool
+	// Settings are the defaults of the settings, overridden by the _settings sheet of each workbook.
+	Settings Settings
+}
+
+func Open(path string, option OpenOption) (*File, error) {
 	file, err := excelize.OpenFile(path)
 	if err != nil {
 		return nil, errs.Wrap(err, "excelize open file")
 	}
 	return &File{
-		path: path,
-		xlsx: file,
-		data: make(map[string]*Sheet),
+		path:   path,
+		option: option,
+		xlsx:   file,
+		data:   make(map[string]*Sheet),
 	}, nil
 }
 
 type File struct {
-	path     string
-	xlsx     *excelize.File
-	data     map[string]*Sheet
-	resolver *ReferenceResolver
+	path        string
+	option      OpenOption
+	xlsx        *excelize.File
+	data        map[string]*Sheet
+	settings    Settings
+	enums       []*Enum
+	constraints []*Constraint
+	resolver    *ReferenceResolver
+	reader      *XLSXReader
 }
 
 func (f *File) Name() string {
@@ -51,19 +71,180 @@ func (f *File) DataSheet(name string) (*Sheet, error) {
 	if sheet, ok := f.data[name]; ok {
 		return sheet, nil
 	}
+	option, err := f.sheetOption(name)
+	if err != nil {
+		return nil, errs.Wrap(err, "build sheet option")
+	}
 	rows, err := f.xlsx.GetRows(name)
 	if err != nil {
 		return nil, errs.Wrap(err, "get data sheet rows")
 	}
-	sheet, err := NewDataSeet(name, rows)
+	option.RawRows, err = f.xlsx.GetRows(name, excelize.Options{RawCellValue: true})
 	if err != nil {
-		return nil, errs.Wrap(err, "parse data sheet")
+		return nil, errs.Wrap(err, "get data sheet raw rows")
+	}
+	if f.option.EvaluateFormulas {
+		if err := f.evaluateFormulas(name, rows, option.RawRows, option); err != nil {
+			return nil, errs.Wrap(errs.WithFile(err, filepath.Base(f.path)), "evaluate formulas")
+		}
+	}
+	sheet, err := NewDataSeet(name, rows, option)
+	if err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(f.path)), "parse data sheet")
+	}
+	if err := f.applyPrimaryKey(sheet); err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(f.path)), "apply primary key")
 	}
 	f.data[name] = sheet
 	return f.data[name], nil
 }
 
+// evaluateFormulas replaces the cached results of the formula cells in rows and rawRows with calculated values.
+// Every formula that cannot be calculated, such as one using an unsupported function, is reported. Comment rows
+// and columns, and the cells past the typed columns, are skipped as NewDataSeet drops them.
+func (f *File) evaluateFormulas(name string, rows, rawRows [][]string, option SheetOption) error {
+	layout := option.Layout.WithDefaults()
+	marker := option.commentMarker()
+	header := func(row int) []string {
+		return lo.NthOr(rows, row-1, nil)
+	}
+
+	var failures []error
+	for i, row := range rows {
+		body := i+1 >= layout.BodyRow
+		for j := range row {
+			if i+1 != layout.NameRow && strings.HasPrefix(lo.NthOr(header(layout.NameRow), j, ""), marker) {
+				continue
+			}
+			if body && j >= len(header(layout.TypeRow)) {
+				break
+			}
+			cell, err := excelize.CoordinatesToCellName(j+1, i+1)
+			if err != nil {
+				return errs.Wrap(err, "build formula cell")
+			}
+			formula, err := f.xlsx.GetCellFormula(name, cell)
+			if err != nil {
+				return errs.Wrap(err, "get cell formula")
+			}
+			if formula != "" {
+				if row[j], err = f.xlsx.CalcCellValue(name, cell); err == nil && i < len(rawRows) && j < len(rawRows[i]) {
+					rawRows[i][j], err = f.xlsx.CalcCellValue(name, cell, excelize.Options{RawCellValue: true})
+				}
+				if err != nil {
+					failures = append(failures, &errs.CellError{Sheet: name, Cell: cell, Err: fmt.Errorf("formula:=%s %w", formula, err)})
+				}
+			}
+			if body && j == 0 && strings.HasPrefix(row[0], marker) {
+				break
+			}
+		}
+	}
+	return errors.Join(failures...)
+}
+
+func (f *File) sheetOption(name string) (SheetOption, error) {
+	settings, err := f.Settings()
+	if err != nil {
+		return SheetOption{}, errs.Wrap(err, "load settings")
+	}
+	option, err := settings.SheetOption(name, f.option.Location)
+	if err != nil {
+		return option, err
+	}
+
+	props, err := f.xlsx.GetWorkbookProps()
+	if err != nil {
+		return option, errs.Wrap(err, "get workbook props")
+	}
+	option.Date1904 = props.Date1904 != nil && *props.Date1904
+
+	if name != TypeDefinitionSheetName {
+		enums, err := f.Enums()
+		if err != nil {
+			return option, errs.Wrap(err, "load enums")
+		}
+		option.Enums = make(map[string]*Enum, len(enums))
+		for _, enum := range enums {
+			option.Enums[enum.Name] = enum
+		}
+	}
+	return option, nil
+}
+
+func (f *File) Settings() (Settings, error) {
+	if f.settings != nil {
+		return f.settings, nil
+	}
+	f.settings = append(Settings{}, f.option.Settings...)
+
+	if index, _ := f.xlsx.GetSheetIndex(SettingSheetName); index < 0 {
+		return f.settings, nil
+	}
+	rows, err := f.xlsx.GetRows(SettingSheetName)
+	if err != nil {
+		return nil, errs.Wrap(err, "get setting rows")
+	}
+	settings, err := NewSettings(NewSettingSheet(SettingSheetName, rows))
+	if err != nil {
+		return nil, errs.Wrap(err, "build settings")
+	}
+	// Workbook settings come last so that they take precedence over the defaults.
+	f.settings = append(f.settings, settings...)
+	return f.settings, nil
+}
+
+func (f *File) applyPrimaryKey(sheet *Sheet) error {
+	constraints, err := f.Constraints()
+	if err != nil {
+		return errs.Wrap(err, "load constraints")
+	}
+	for _, constraint := range constraints {
+		if constraint.Sheet != sheet.Name || !constraint.PrimaryKey {
+			continue
+		}
+		column, err := sheet.Column(constraint.Column)
+		if err != nil {
+			return errs.Wrap(err, "find primary key column")
+		}
+		sheet.PrimaryKey = append(sheet.PrimaryKey, column)
+	}
+	return sheet.ValidatePrimaryKey()
+}
+
+func (f *File) Enums() ([]*Enum, error) {
+	if f.enums != nil {
+		return f.enums, nil
+	}
+	f.enums = []*Enum{}
+
+	if index, _ := f.xlsx.GetSheetIndex(TypeDefinitionSheetName); index < 0 {
+		return f.enums, nil
+	}
+	sheet, err := f.DataSheet(TypeDefinitionSheetName)
+	if err != nil {
+		return nil, errs.Wrap(err, "load type definition sheet")
+	}
+	enums, err := NewEnums(sheet)
+	if err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(f.path)), "build enums")
+	}
+	f.enums = enums
+	return f.enums, nil
+}
+
+// sheetReader returns the reader of the reference files, which also serves this workbook itself.
+func (f *File) sheetReader() *XLSXReader {
+	if f.reader == nil {
+		f.reader = &XLSXReader{option: f.option, file: map[string]*File{filepath.Clean(f.path): f}}
+	}
+	return f.reader
+}
+
 func (f *File) ReferenceDefinitionSheet() (*Sheet, error) {
+	if index, _ := f.xlsx.GetSheetIndex(ReferenceDefinitionSheetName); index < 0 {
+		return NewReferenceDefinitionSheet(ReferenceDefinitionSheetName, nil), nil
+	}
 	rows, err := f.xlsx.GetRows(ReferenceDefinitionSheetName)
 	if err != nil {
 		return nil, errs.Wrap(err, "get reference definition rows")
@@ -71,6 +252,108 @@ func (f *File) ReferenceDefinitionSheet() (*Sheet, error) {
 	return NewReferenceDefinitionSheet(ReferenceDefinitionSheetName, rows), nil
 }
 
+func (f *File) ConstraintDefinitionSheet() (*Sheet, error) {
+	rows, err := f.xlsx.GetRows(ConstraintDefinitionSheetName)
+	if err != nil {
+		return nil, errs.Wrap(err, "get constraint definition rows")
+	}
+	return NewConstraintDefinitionSheet(ConstraintDefinitionSheetName, rows), nil
+}
+
+func (f *File) Constraints() ([]*Constraint, error) {
+	if f.constraints != nil {
+		return f.constraints, nil
+	}
+	if index, _ := f.xlsx.GetSheetIndex(ConstraintDefinitionSheetName); index < 0 {
+		f.constraints = []*Constraint{}
+		return f.constraints, nil
+	}
+	sheet, err := f.ConstraintDefinitionSheet()
+	if err != nil {
+		return nil, errs.Wrap(err, "load constraint definition sheet")
+	}
+	constraints, err := NewConstraints(sheet)
+	if err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(f.path)), "build constraints")
+	}
+	f.constraints = constraints
+	return f.constraints, nil
+}
+
+func (f *File) validate(constraints []*Constraint, sheets map[string]*Sheet) []error {
+	var failures []error
+	for _, constraint := range constraints {
+		if sheet, ok := sheets[constraint.Sheet]; ok {
+			failures = append(failures, constraint.Validate(sheet)...)
+		}
+	}
+	return failures
+}
+
+// loadSheets loads every named sheet once, collecting the errors instead of stopping at the first one.
+func (f *File) loadSheets(names []string) (map[string]*Sheet, []error) {
+	sheets := make(map[string]*Sheet)
+	loaded := make(map[string]bool)
+	var failures []error
+	for _, name := range names {
+		if loaded[name] {
+			continue
+		}
+		loaded[name] = true
+
+		sheet, err := f.DataSheet(name)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		sheets[name] = sheet
+	}
+	return sheets, failures
+}
+
+// resolvedDataSheets loads, validates and resolves the data sheets in workbook order.
+// All problems found on the way are reported together so that they can be fixed in one pass.
+func (f *File) resolvedDataSheets() ([]*Sheet, error) {
+	constraints, err := f.Constraints()
+	if err != nil {
+		return nil, errs.Wrap(err, "load constraints")
+	}
+	resolver, err := f.ReferenceResolver()
+	if err != nil {
+		return nil, errs.Wrap(err, "load reference resolver")
+	}
+
+	var names []string
+	for _, name := range f.xlsx.GetSheetList() {
+		if !strings.HasPrefix(name, "_") {
+			names = append(names, name)
+		}
+	}
+	dataSheetCount := len(names)
+	for _, constraint := range constraints {
+		names = append(names, constraint.Sheet)
+	}
+	sheets, failures := f.loadSheets(names)
+	failures = append(failures, f.validate(constraints, sheets)...)
+
+	var dataSheets []*Sheet
+	for _, name := range names[:dataSheetCount] {
+		sheet, ok := sheets[name]
+		if !ok {
+			continue
+		}
+		if err := resolver.Resolve(sheet); err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		dataSheets = append(dataSheets, sheet)
+	}
+	if len(failures) > 0 {
+		return nil, errs.WithFile(errors.Join(failures...), filepath.Base(f.path))
+	}
+	return dataSheets, nil
+}
+
 func (f *File) ReferenceResolver() (*ReferenceResolver, error) {
 	if f.resolver != nil {
 		return f.resolver, nil
@@ -92,9 +375,11 @@ func (f *File) DeleteReferenceData() {
 	f.xlsx.NewSheet(ReferenceDataSheetName)
 }
 
+// DeleteDefinedNames deletes the defined names written by UpdateReferenceData, which all refer to the
+// _reference_data sheet. Other defined names are kept.
 func (f *File) DeleteDefinedNames() {
 	for _, n := range f.xlsx.GetDefinedName() {
-		if strings.HasPrefix("_", n.Name) {
+		if !ownsDefinedName(n) {
 			continue
 		}
 		if err := f.xlsx.DeleteDefinedName(&n); err != nil {
@@ -103,9 +388,15 @@ func (f *File) DeleteDefinedNames() {
 	}
 }
 
+func ownsDefinedName(n excelize.DefinedName) bool {
+	refersTo := strings.TrimPrefix(n.RefersTo, "=")
+	return strings.HasPrefix(refersTo, ReferenceDataSheetName+"!") || strings.HasPrefix(refersTo, "'"+ReferenceDataSheetName+"'!")
+}
+
 func (f *File) UpdateReferenceData() error {
-	f.DeleteReferenceData()
+	// The names are deleted first, while they can still be told apart by the sheet they refer to.
 	f.DeleteDefinedNames()
+	f.DeleteReferenceData()
 
 	resolver, err := f.ReferenceResolver()
 	if err != nil {
@@ -156,32 +447,119 @@ func (f *File) UpdateReferenceData() error {
 	return nil
 }
 
+// DeleteDataValidations deletes the data validations added by UpdateDataValidations from the data sheets:
+// drop-down lists sourced from the _reference_data or _types sheet, and the lists of polymorphic references.
+// Validations added by hand are kept as they are.
 func (f *File) DeleteDataValidations() error {
-	m := make(map[string][]string)
+	keyColumns, err := f.polymorphicKeyColumns()
+	if err != nil {
+		return errs.Wrap(err, "find polymorphic reference key columns")
+	}
+	for _, name := range f.xlsx.GetSheetList() {
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		dvs, err := f.xlsx.GetDataValidations(name)
+		if err != nil {
+			return errs.Wrap(err, "get data validations")
+		}
+		if !lo.SomeBy(dvs, func(dv *excelize.DataValidation) bool {
+			return ownsDataValidation(dv, keyColumns[name])
+		}) {
+			continue
+		}
+		// Deleting a validation by its sqref also takes its cells out of the other validations over them, so
+		// the validations are cleared at once and the ones added by hand are added back.
+		if err := f.xlsx.DeleteDataValidation(name); err != nil {
+			return errs.Wrap(err, "delete data validations")
+		}
+		// The validations of the extension list, which come last, are not cleared.
+		rest, err := f.xlsx.GetDataValidations(name)
+		if err != nil {
+			return errs.Wrap(err, "get data validations")
+		}
+		for _, dv := range dvs[:len(dvs)-len(rest)] {
+			if ownsDataValidation(dv, keyColumns[name]) {
+				slog.Debug("DeleteDataValidation", "sheet", name, "sqref", dv.Sqref)
+				continue
+			}
+			if err := f.xlsx.AddDataValidation(name, escapeDataValidation(dv)); err != nil {
+				return errs.Wrap(err, "restore data validation")
+			}
+		}
+	}
+	return nil
+}
 
+// escapeDataValidation escapes again the formulas of a data validation read by GetDataValidations, which unescapes
+// them, so that it is added back unchanged. The quotes of a formula quoted as a whole, a list of values, are doubled.
+func escapeDataValidation(dv *excelize.DataValidation) *excelize.DataValidation {
+	escape := func(formula string) string {
+		formula = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(formula)
+		if len(formula) >= 2 && strings.HasPrefix(formula, `"`) && strings.HasSuffix(formula, `"`) {
+			return `"` + strings.ReplaceAll(formula[1:len(formula)-1], `"`, `""`) + `"`
+		}
+		return formula
+	}
+	escaped := *dv
+	escaped.Formula1 = escape(dv.Formula1)
+	escaped.Formula2 = escape(dv.Formula2)
+	return &escaped
+}
+
+// indirectFormulaRegexp matches the drop-down list source of a polymorphic reference, the defined name held
+// by a cell of the key column, wherever the row of that cell is.
+var indirectFormulaRegexp = regexp.MustCompile(`^INDIRECT\(\$([A-Z]+)\$?\d+\)$`)
+
+func ownsDataValidation(dv *excelize.DataValidation, polymorphicKeyColumns []string) bool {
+	if dv.Type != "list" {
+		return false
+	}
+	for _, sheet := range []string{ReferenceDataSheetName, TypeDefinitionSheetName} {
+		if strings.HasPrefix(dv.Formula1, sheet+"!") || strings.HasPrefix(dv.Formula1, "'"+sheet+"'!") {
+			return true
+		}
+	}
+	m := indirectFormulaRegexp.FindStringSubmatch(dv.Formula1)
+	return m != nil && lo.Contains(polymorphicKeyColumns, m[1])
+}
+
+// polymorphicKeyColumns returns the names of the key columns of the polymorphic references, keyed by sheet.
+func (f *File) polymorphicKeyColumns() (map[string][]string, error) {
 	resolver, err := f.ReferenceResolver()
 	if err != nil {
-		return errs.Wrap(err, "load reference resolver")
+		return nil, errs.Wrap(err, "load reference resolver")
 	}
-	for _, referenceDefinition := range resolver.ReferenceDefinitions {
-		if referenceDefinition.Sheet == "" {
+	columns := make(map[string][]string)
+	for _, definition := range resolver.ReferenceDefinitions {
+		if definition.Sheet == "" || !definition.PolymorphicReference() {
 			continue
 		}
-		sheet, err := f.DataSheet(referenceDefinition.Sheet)
+		sheet, err := f.DataSheet(definition.Sheet)
+		if err != nil {
+			return nil, errs.Wrap(err, "load data sheet for validation deletion")
+		}
+		column, err := sheet.Column(definition.ReferenceKey)
 		if err != nil {
-			return errs.Wrap(err, "load data sheet for validation deletion")
+			return nil, errs.Wrap(err, "find polymorphic key column")
 		}
-		sqref, _, err := sheet.Sqrefs(referenceDefinition)
+		name, err := excelize.ColumnNumberToName(column.Index + 1)
 		if err != nil {
-			return errs.Wrap(err, "build sqref for validation deletion")
+			return nil, errs.Wrap(err, "build polymorphic key column name")
 		}
-		m[referenceDefinition.Sheet] = append(m[referenceDefinition.Sheet], sqref)
+		columns[definition.Sheet] = append(columns[definition.Sheet], name)
 	}
-	for name := range m {
-		f.xlsx.DeleteDataValidation(name) // FIXME: pass sqref
-		slog.Debug("DeleteDataValidations", "sheet", name)
+	return columns, nil
+}
+
+// polymorphicFormula returns the drop-down list source of a polymorphic reference, which is the defined name
+// held by the key column of the first data row.
+func polymorphicFormula(sheet *Sheet, keyColumn *Column) (string, error) {
+	name, err := excelize.ColumnNumberToName(keyColumn.Index + 1)
+	if err != nil {
+		return "", errs.Wrap(err, "build indirect column name")
 	}
-	return nil
+	return fmt.Sprintf("INDIRECT($%s%d)", name, sheet.Layout.WithDefaults().BodyRow), nil
 }
 
 func (f *File) UpdateDataValidations() error {
@@ -198,25 +576,34 @@ func (f *File) UpdateDataValidations() error {
 		return errs.Wrap(err, "load references")
 	}
 	for _, reference := range references {
-		if reference.Definition.Sheet == "" {
+		// A composite key split over several columns cannot be picked from a single drop-down list.
+		if reference.Definition.Sheet == "" || len(reference.Definition.Columns()) > 1 {
 			continue
 		}
 		sheet, err := f.DataSheet(reference.Definition.Sheet)
 		if err != nil {
 			return errs.Wrap(err, "load data sheet for validation update")
 		}
-		sqref, srcSqref, err := sheet.Sqrefs(reference.Definition)
+		if column, err := sheet.Column(reference.Definition.Column); err == nil && column.Type.IsList() {
+			// A drop-down list picks a single key, so lists of keys are left unvalidated.
+			continue
+		}
+		headroom, err := f.validationHeadroom(sheet.Name)
+		if err != nil {
+			return errs.Wrap(err, "load validation headroom")
+		}
+		sqref, srcSqref, err := sheet.Sqrefs(reference.Definition, len(reference.Keys), headroom)
 		if err != nil {
 			return errs.Wrap(err, "build sqref for validation update")
 		}
 		dvRange := excelize.NewDataValidation(true)
 		dvRange.Sqref = sqref
 		if reference.Definition.PolymorphicReference() {
-			name, err := excelize.ColumnNumberToName(reference.KeyColumn.Index + 1)
+			formula, err := polymorphicFormula(sheet, reference.KeyColumn)
 			if err != nil {
-				return errs.Wrap(err, "build indirect column name")
+				return err
 			}
-			dvRange.SetSqrefDropList(fmt.Sprintf("INDIRECT($%s4)", name))
+			dvRange.SetSqrefDropList(formula)
 		} else {
 			dvRange.SetSqrefDropList(ReferenceDataSheetName + "!" + srcSqref)
 		}
@@ -224,27 +611,105 @@ func (f *File) UpdateDataValidations() error {
 
 		slog.Debug("AddDataValidation", "sheet", reference.Definition.Sheet, "dv", dvRange)
 	}
-	return nil
+	return f.updateEnumDataValidations()
 }
 
-func (f *File) Export(exporter Exporter) error {
-	resolver, err := f.ReferenceResolver()
+func (f *File) validationHeadroom(name string) (int, error) {
+	settings, err := f.Settings()
 	if err != nil {
-		return errs.Wrap(err, "load reference resolver")
+		return 0, errs.Wrap(err, "load settings")
 	}
+	return settings.ValidationHeadroom(name)
+}
 
+func (f *File) updateEnumDataValidations() error {
+	enums, err := f.Enums()
+	if err != nil {
+		return errs.Wrap(err, "load enums")
+	}
 	for _, name := range f.xlsx.GetSheetMap() {
 		if strings.HasPrefix(name, "_") {
 			continue
 		}
-
 		sheet, err := f.DataSheet(name)
 		if err != nil {
-			return errs.Wrap(err, "load export target sheet")
+			return errs.Wrap(err, "load data sheet for enum validation update")
 		}
-		if err := resolver.Resolve(sheet); err != nil {
-			return errs.Wrap(err, "resolve references")
+		headroom, err := f.validationHeadroom(name)
+		if err != nil {
+			return errs.Wrap(err, "load validation headroom")
+		}
+		for _, column := range sheet.Columns {
+			if !column.Type.NonNull().IsEnum() {
+				continue
+			}
+			for _, enum := range enums {
+				if enum.Name != column.Type.NonNull().EnumName() {
+					continue
+				}
+				sqref, err := sheet.ColumnSqref(column, headroom)
+				if err != nil {
+					return errs.Wrap(err, "build enum sqref")
+				}
+				srcSqref, err := enum.Sqref()
+				if err != nil {
+					return errs.Wrap(err, "build enum source sqref")
+				}
+				dvRange := excelize.NewDataValidation(true)
+				dvRange.Sqref = sqref
+				dvRange.SetSqrefDropList(TypeDefinitionSheetName + "!" + srcSqref)
+				f.xlsx.AddDataValidation(name, dvRange)
+
+				slog.Debug("AddDataValidation", "sheet", name, "dv", dvRange)
+			}
+		}
+	}
+	return nil
+}
+
+// SheetFilter narrows down the sheets passed to exporters and generators.
+type SheetFilter struct {
+	// Target drops the columns that are not exported to the target. Every column is kept if empty.
+	Target string
+	// Sheets keeps the sheets whose names match one of the patterns, such as items or item_*.
+	// Every sheet is kept if empty.
+	Sheets []string
+}
+
+func (s SheetFilter) Apply(sheet *Sheet) *Sheet {
+	return sheet.ForTarget(s.Target)
+}
+
+// Match reports whether the sheet is kept by the filter.
+func (s SheetFilter) Match(sheet *Sheet) bool {
+	if len(s.Sheets) == 0 {
+		return true
+	}
+	for _, pattern := range s.Sheets {
+		if ok, _ := path.Match(pattern, sheet.Name); ok {
+			return true
 		}
+	}
+	return false
+}
+
+// Select returns the sheets kept by the filter, applying the filter to each of them.
+func (s SheetFilter) Select(sheets []*Sheet) []*Sheet {
+	var selected []*Sheet
+	for _, sheet := range sheets {
+		if s.Match(sheet) {
+			selected = append(selected, s.Apply(sheet))
+		}
+	}
+	return selected
+}
+
+func (f *File) Export(exporter Exporter, filter SheetFilter) error {
+	sheets, err := f.resolvedDataSheets()
+	if err != nil {
+		return errs.Wrap(err, "load export target sheets")
+	}
+	for _, sheet := range filter.Select(sheets) {
 		if err := exporter.Export(sheet); err != nil {
 			return errs.Wrap(err, "export sheet")
 		}
@@ -256,24 +721,25 @@ func (f *File) ExportMetadata(outDir string) error {
 	return NewMetadataExporter(outDir).Export(f)
 }
 
-func (f *File) Generate(generator Generator) error {
-	resolver, err := f.ReferenceResolver()
+func (f *File) Generate(generator Generator, filter SheetFilter) error {
+	sheets, err := f.resolvedDataSheets()
 	if err != nil {
-		return errs.Wrap(err, "load reference resolver")
+		return errs.Wrap(err, "load generate target sheets")
 	}
 
-	for _, name := range f.xlsx.GetSheetMap() {
-		if strings.HasPrefix(name, "_") {
-			continue
-		}
-
-		sheet, err := f.DataSheet(name)
+	if g, ok := generator.(EnumGenerator); ok {
+		enums, err := f.Enums()
 		if err != nil {
-			return errs.Wrap(err, "load generate target sheet")
+			return errs.Wrap(err, "load enums")
 		}
-		if err := resolver.Resolve(sheet); err != nil {
-			return errs.Wrap(err, "resolve references")
+		if len(enums) > 0 {
+			if err := g.GenerateEnums(enums); err != nil {
+				return errs.Wrap(err, "generate enums")
+			}
 		}
+	}
+
+	for _, sheet := range filter.Select(sheets) {
 		if err := generator.Generate(sheet); err != nil {
 			return errs.Wrap(err, "generate code")
 		}
@@ -1,12 +1,16 @@
 package exceref_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 	"github.com/xuri/excelize/v2"
 
+	"github.com/daichirata/exceref/internal/errs"
 	"github.com/daichirata/exceref/internal/exceref"
 )
 
@@ -40,7 +44,7 @@ func TestFile_UpdateReferenceData_EmptyReferenceSource(t *testing.T) {
 	require.NoError(t, targetBook.SaveAs(targetPath))
 	require.NoError(t, targetBook.Close())
 
-	file, err := exceref.Open(targetPath)
+	file, err := exceref.Open(targetPath, exceref.OpenOption{})
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		require.NoError(t, file.Close())
@@ -48,3 +52,589 @@ func TestFile_UpdateReferenceData_EmptyReferenceSource(t *testing.T) {
 
 	require.NoError(t, file.UpdateReferenceData())
 }
+
+func TestFile_UpdateDataValidations_Enum(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "items"))
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string", "enum:rarity"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"id", "rarity"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"sword", "rare"}))
+	_, err := book.NewSheet(exceref.TypeDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.TypeDefinitionSheetName, "A1", &[]any{"string", "string"}))
+	require.NoError(t, book.SetSheetRow(exceref.TypeDefinitionSheetName, "A2", &[]any{"element", "rarity"}))
+	require.NoError(t, book.SetSheetRow(exceref.TypeDefinitionSheetName, "A4", &[]any{"fire", "common"}))
+	require.NoError(t, book.SetSheetRow(exceref.TypeDefinitionSheetName, "A5", &[]any{"water", "rare"}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+
+	require.NoError(t, file.UpdateDataValidations())
+	require.NoError(t, file.Save())
+
+	saved, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, saved.Close())
+	})
+	dvs, err := saved.GetDataValidations("items")
+	require.NoError(t, err)
+	require.Len(t, dvs, 1)
+	require.Equal(t, "B4:B1004", dvs[0].Sqref)
+	require.Equal(t, "_types!$B$4:$B$5", dvs[0].Formula1)
+}
+
+func TestFile_DataSheet_FormattedNumbers(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "items"))
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string", "int", "float", "float?"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"id", "price", "rate", "bonus"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"sword", 1000, 0.25, 1234.5}))
+	require.NoError(t, book.SetSheetRow("items", "A5", &[]any{"1,000", 12, 1}))
+	thousands, err := book.NewStyle(&excelize.Style{NumFmt: 3})
+	require.NoError(t, err)
+	percent, err := book.NewStyle(&excelize.Style{NumFmt: 9})
+	require.NoError(t, err)
+	currency, err := book.NewStyle(&excelize.Style{NumFmt: 164, CustomNumFmt: lo.ToPtr(`"$"#,##0.00`)})
+	require.NoError(t, err)
+	require.NoError(t, book.SetCellStyle("items", "B4", "B5", thousands))
+	require.NoError(t, book.SetCellStyle("items", "C4", "C5", percent))
+	require.NoError(t, book.SetCellStyle("items", "D4", "D4", currency))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+
+	sheet, err := file.DataSheet("items")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"id": "sword", "price": 1000, "rate": 0.25, "bonus": 1234.5},
+		{"id": "1,000", "price": 12, "rate": float64(1), "bonus": nil},
+	}, sheet.Map())
+}
+
+func TestFile_DataSheet_DateCells(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "events"))
+	require.NoError(t, book.SetSheetRow("events", "A1", &[]any{"datetime", "date", "unixtime"}))
+	require.NoError(t, book.SetSheetRow("events", "A2", &[]any{"start_at", "day", "end_at"}))
+	start := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+	require.NoError(t, book.SetSheetRow("events", "A4", &[]any{start, start, start}))
+	style, err := book.NewStyle(&excelize.Style{NumFmt: 14})
+	require.NoError(t, err)
+	require.NoError(t, book.SetCellStyle("events", "B4", "B4", style))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+
+	sheet, err := file.DataSheet("events")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"start_at": start, "day": "2024-01-02", "end_at": start.Unix()},
+	}, sheet.Map())
+}
+
+func TestFile_DataSheet_EvaluateFormulas(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "int", "int", "string"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "level", "hp", "code"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", 3}))
+	require.NoError(t, book.SetCellFormula("units", "C4", "B4*1000"))
+	require.NoError(t, book.SetCellFormula("units", "D4", `"unit_"&A4`))
+	thousands, err := book.NewStyle(&excelize.Style{NumFmt: 3})
+	require.NoError(t, err)
+	require.NoError(t, book.SetCellStyle("units", "C4", "C4", thousands))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	sheet, err := file.DataSheet("units")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"name": "knight", "level": 3, "hp": 0, "code": ""}}, sheet.Map())
+
+	file, err = exceref.Open(path, exceref.OpenOption{EvaluateFormulas: true})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	sheet, err = file.DataSheet("units")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{{"name": "knight", "level": 3, "hp": 3000, "code": "unit_knight"}}, sheet.Map())
+}
+
+func TestFile_DataSheet_EvaluateFormulasUnsupported(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "string", "", "string"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "code", "#memo", "rank"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight"}))
+	require.NoError(t, book.SetSheetRow("units", "A5", &[]any{"# archer"}))
+	for _, cell := range []string{"B4", "C4", "E4", "B5"} {
+		require.NoError(t, book.SetCellFormula("units", cell, `WEBSERVICE("https://example.com")`))
+	}
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{EvaluateFormulas: true})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	_, err = file.DataSheet("units")
+	require.Error(t, err)
+	// Only B4 is reported, since the comment column C, the cell E4 past the typed columns and the comment row 5
+	// are not read.
+	failures := errs.Flatten(err)
+	require.Len(t, failures, 1)
+	require.EqualError(t, failures[0], `book.xlsx:units!B4: formula:=WEBSERVICE("https://example.com") not support WEBSERVICE function`)
+}
+
+func TestFile_Export_EmbedReference(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "weapon"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", "sword"}))
+	_, err := book.NewSheet("weapons")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("weapons", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A2", &[]any{"code", "element"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A4", &[]any{"sword", "fire"}))
+	_, err = book.NewSheet("elements")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("elements", "A1", &[]any{"string", "int"}))
+	require.NoError(t, book.SetSheetRow("elements", "A2", &[]any{"code", "id"}))
+	require.NoError(t, book.SetSheetRow("elements", "A4", &[]any{"fire", 1}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value", "embed"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "weapon", "book", "weapons", "code", "", "*"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A3",
+		&[]any{"weapons", "element", "book", "elements", "code", "id", ""}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"name":"knight","weapon":{"code":"sword","element":1}}]`+"\n", string(body))
+	body, err = os.ReadFile(filepath.Join(dir, "weapons.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"code":"sword","element":1}]`+"\n", string(body))
+}
+
+func TestFile_Export_EmbedReferenceTarget(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref", "[]ref"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "weapon", "spares"}))
+	require.NoError(t, book.SetSheetRow("units", "A5", &[]any{"knight", "sword", "sword"}))
+	_, err := book.NewSheet("weapons")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("weapons", "A1", &[]any{"string", "int", "int"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A2", &[]any{"code", "power", "drop_rate"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A4", &[]any{"", "", "server"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A5", &[]any{"sword", 10, 3}))
+	_, err = book.NewSheet(exceref.SettingSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.SettingSheetName, "A1", &[]any{"sheet", "key", "value"}))
+	require.NoError(t, book.SetSheetRow(exceref.SettingSheetName, "A2", &[]any{"", "target_row", "4"}))
+	require.NoError(t, book.SetSheetRow(exceref.SettingSheetName, "A3", &[]any{"", "body_row", "5"}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "embed"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "weapon", "", "weapons", "code", "*"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A3",
+		&[]any{"units", "spares", "", "weapons", "code", "*"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+
+	client := filepath.Join(dir, "client")
+	require.NoError(t, os.Mkdir(client, 0o755))
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: client}), exceref.SheetFilter{Target: "client"}))
+	body, err := os.ReadFile(filepath.Join(client, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"name":"knight","spares":[{"code":"sword","power":10}],"weapon":{"code":"sword","power":10}}]`+"\n", string(body))
+
+	server := filepath.Join(dir, "server")
+	require.NoError(t, os.Mkdir(server, 0o755))
+	require.NoError(t, file.Export(exceref.NewCSVExporter(exceref.ExportOption{OutDir: server}), exceref.SheetFilter{Target: "server"}))
+	body, err = os.ReadFile(filepath.Join(server, "units.csv"))
+	require.NoError(t, err)
+	require.Equal(t, "name,weapon,spares\nknight,\"{\"\"code\"\":\"\"sword\"\",\"\"drop_rate\"\":3,\"\"power\"\":10}\",\"{\"\"code\"\":\"\"sword\"\",\"\"drop_rate\"\":3,\"\"power\"\":10}\"\n", string(body))
+}
+
+func TestFile_Export_EmbedReferenceCycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "nodes"))
+	require.NoError(t, book.SetSheetRow("nodes", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("nodes", "A2", &[]any{"code", "parent"}))
+	require.NoError(t, book.SetSheetRow("nodes", "A4", &[]any{"root", ""}))
+	require.NoError(t, book.SetSheetRow("nodes", "A5", &[]any{"leaf", "root"}))
+	_, err := book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "embed"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"nodes", "parent", "book", "nodes", "code", "*"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	err = file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{})
+	require.Error(t, err)
+	require.Equal(t, []string{"book.xlsx:nodes: reference cycle detected"}, lo.Map(errs.Flatten(err), func(e error, _ int) string {
+		return e.Error()
+	}))
+}
+
+func TestFile_Export_CSVReference(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "items.csv"), []byte("string,int\ncode,id\nname,id\nsword,1\n"), 0o644))
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "item"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", "sword"}))
+	_, err := book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "item", "items.csv", "items", "code", "id"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"item":1,"name":"knight"}]`+"\n", string(body))
+}
+
+func TestFile_Export_SameWorkbookReference(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "renamed.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref", "ref"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "item", "weapon"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", "sword", "sword"}))
+	_, err := book.NewSheet("items")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string", "int"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"code", "id"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"sword", 1}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value", "embed"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "item", "", "items", "code", "id", ""}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A3",
+		&[]any{"units", "weapon", ".", "items", "code", "", "*"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"item":1,"name":"knight","weapon":{"code":"sword","id":1}}]`+"\n", string(body))
+}
+
+func TestFile_Export_ReferenceToReferenceColumn(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "weapons"))
+	require.NoError(t, book.SetSheetRow("weapons", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A2", &[]any{"code", "element"}))
+	require.NoError(t, book.SetSheetRow("weapons", "A4", &[]any{"sword", "fire"}))
+	_, err := book.NewSheet("units")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "element"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", "sword"}))
+	_, err = book.NewSheet("elements")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("elements", "A1", &[]any{"string", "int"}))
+	require.NoError(t, book.SetSheetRow("elements", "A2", &[]any{"code", "id"}))
+	require.NoError(t, book.SetSheetRow("elements", "A4", &[]any{"fire", 1}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "element", "", "weapons", "code", "element"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A3",
+		&[]any{"weapons", "element", "", "elements", "code", "id"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+
+	// References read the keys of weapons as written, whether weapons is resolved before or after,
+	// so units takes them typed as they are written.
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"element":"fire","name":"knight"}]`+"\n", string(body))
+	sheet, err := file.DataSheet("units")
+	require.NoError(t, err)
+	column, err := sheet.Column("element")
+	require.NoError(t, err)
+	require.Equal(t, exceref.ColumnTypeRef, column.Type)
+	body, err = os.ReadFile(filepath.Join(dir, "weapons.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"code":"sword","element":1}]`+"\n", string(body))
+}
+
+func TestFile_UpdateDataValidations_PolymorphicReference(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "rewards"))
+	require.NoError(t, book.SetSheetRow("rewards", "A1", &[]any{"string", "ref", "string"}))
+	require.NoError(t, book.SetSheetRow("rewards", "A2", &[]any{"kind", "target", "memo"}))
+	require.NoError(t, book.SetSheetRow("rewards", "A4", &[]any{"item", "sword", "a"}))
+	_, err := book.NewSheet("items")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string", "int"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"code", "id"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"sword", 1}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value", "reference_name"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"rewards", "target", "", "rewards", "kind"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A3",
+		&[]any{"", "", "", "items", "code", "id", "item"}))
+	// A drop-down list left by an update over another first data row is replaced, and one over a column
+	// that is not a polymorphic key is kept.
+	for sqref, formula := range map[string]string{"B5:B20": "INDIRECT($A5)", "C4:C10": "INDIRECT($C4)"} {
+		dv := excelize.NewDataValidation(true)
+		dv.Sqref = sqref
+		dv.SetSqrefDropList(formula)
+		require.NoError(t, book.AddDataValidation("rewards", dv))
+	}
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{})
+	require.NoError(t, err)
+	require.NoError(t, file.UpdateReferenceData())
+	require.NoError(t, file.UpdateDataValidations())
+	require.NoError(t, file.Save())
+	require.NoError(t, file.Close())
+
+	saved, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, saved.Close())
+	})
+	dvs, err := saved.GetDataValidations("rewards")
+	require.NoError(t, err)
+	require.Equal(t, []string{"C4:C10 INDIRECT($C4)", "B4:B1004 INDIRECT($A4)"}, lo.Map(dvs, func(dv *excelize.DataValidation, _ int) string {
+		return dv.Sqref + " " + dv.Formula1
+	}))
+}
+
+func TestSheetFilter_Select(t *testing.T) {
+	t.Parallel()
+
+	sheets := []*exceref.Sheet{{Name: "items"}, {Name: "item_rewards"}, {Name: "units"}}
+	require.Len(t, exceref.SheetFilter{}.Select(sheets), 3)
+	require.Equal(t, []string{"items", "item_rewards"}, lo.Map(exceref.SheetFilter{Sheets: []string{"item*"}}.Select(sheets), func(s *exceref.Sheet, _ int) string {
+		return s.Name
+	}))
+}
+
+func TestFile_Settings_Defaults(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "items"))
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string", "int"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"code", "id"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"note", "note"}))
+	require.NoError(t, book.SetSheetRow("items", "A5", &[]any{"sword", 1}))
+	_, err := book.NewSheet(exceref.SettingSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.SettingSheetName, "A1", &[]any{"sheet", "key", "value"}))
+	require.NoError(t, book.SetSheetRow(exceref.SettingSheetName, "A2", &[]any{"", "body_row", "5"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	file, err := exceref.Open(path, exceref.OpenOption{Settings: exceref.Settings{
+		{Key: exceref.SettingKeyBodyRow, Value: "6"},
+		{Key: exceref.SettingKeyDescriptionRow, Value: "4"},
+	}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, file.Close())
+	})
+	sheet, err := file.DataSheet("items")
+	require.NoError(t, err)
+	require.Equal(t, "note", sheet.Columns[0].Description)
+	require.Equal(t, []map[string]any{{"code": "sword", "id": 1}}, sheet.Map())
+}
+
+func TestFile_Update_KeepsUserDefinitions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "units"))
+	require.NoError(t, book.SetSheetRow("units", "A1", &[]any{"string", "ref", "string"}))
+	require.NoError(t, book.SetSheetRow("units", "A2", &[]any{"name", "item", "memo"}))
+	require.NoError(t, book.SetSheetRow("units", "A4", &[]any{"knight", "sword", "a"}))
+	_, err := book.NewSheet("items")
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow("items", "A1", &[]any{"string"}))
+	require.NoError(t, book.SetSheetRow("items", "A2", &[]any{"code"}))
+	require.NoError(t, book.SetSheetRow("items", "A4", &[]any{"sword"}))
+	_, err = book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value", "reference_name"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2",
+		&[]any{"units", "item", "", "items", "code", "code", "ItemCodes"}))
+	// The validation added by hand overlaps the drop-down list of item, and is kept whole.
+	dv := excelize.NewDataValidation(true)
+	dv.Sqref = "B4:C10"
+	require.NoError(t, dv.SetDropList([]string{`say "hi"`, "a&b"}))
+	require.NoError(t, book.AddDataValidation("units", dv))
+	require.NoError(t, book.SetDefinedName(&excelize.DefinedName{Name: "UnitNames", RefersTo: "units!$A$4:$A$10"}))
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+
+	for i := 0; i < 2; i++ {
+		file, err := exceref.Open(path, exceref.OpenOption{})
+		require.NoError(t, err)
+		require.NoError(t, file.UpdateReferenceData())
+		require.NoError(t, file.UpdateDataValidations())
+		require.NoError(t, file.Save())
+		require.NoError(t, file.Close())
+	}
+
+	saved, err := excelize.OpenFile(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, saved.Close())
+	})
+	dvs, err := saved.GetDataValidations("units")
+	require.NoError(t, err)
+	require.Equal(t, []string{"B4:C10", "B4:B1004"}, lo.Map(dvs, func(dv *excelize.DataValidation, _ int) string {
+		return dv.Sqref
+	}))
+	require.Equal(t, `"say "hi",a&b"`, dvs[0].Formula1)
+	require.Equal(t, []string{"UnitNames", "ItemCodes"}, lo.Map(saved.GetDefinedName(), func(n excelize.DefinedName, _ int) string {
+		return n.Name
+	}))
+}
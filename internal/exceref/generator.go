@@ -2,27 +2,43 @@ package exceref
 
 import (
 	"bytes"
+	"fmt"
 	"go/format"
+	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/daichirata/exceref/internal/errs"
 	"github.com/gobuffalo/flect"
 	"github.com/iancoleman/strcase"
 	"github.com/jinzhu/inflection"
+	"github.com/samber/lo"
 )
 
 type GenerateOption struct {
 	Prefix       string
 	OutDir       string
 	TemplatePath string
+	// Package is the package name of generated go enums. The base name of OutDir is used if empty.
+	Package string
 }
 
 type Generator interface {
 	Generate(sheet *Sheet) error
 }
 
+// EnumGenerator is implemented by generators that emit the enums of the _types sheet.
+type EnumGenerator interface {
+	GenerateEnums(enums []*Enum) error
+}
+
+// GenerateLangs are the languages BuildGenerator builds a generator for.
+var GenerateLangs = []string{"go", "csharp", "generic"}
+
 func BuildGenerator(lang string, option GenerateOption) Generator {
 	switch lang {
 	case "go":
@@ -44,6 +60,64 @@ type Field struct {
 	Name       string
 	ColumnName string
 	Type       string
+	PrimaryKey bool
+}
+
+// Struct is a nested type built from dotted column names such as reward.item_id.
+type Struct struct {
+	Name   string
+	Fields []*Field
+}
+
+// fieldBuilder builds template fields from columns using the naming rules of a language.
+type fieldBuilder struct {
+	fieldName func(string) string
+	typeName  func(ColumnType) string
+	listOf    func(string) string
+}
+
+func (b *fieldBuilder) build(name string, columns []*Column, primaryKey []*Column) ([]*Field, []*Struct, error) {
+	root, err := newColumnTree(columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	var structs []*Struct
+	fields := b.fields(name, root, &structs)
+	for _, field := range fields {
+		for _, column := range primaryKey {
+			if field.ColumnName == column.Name {
+				field.PrimaryKey = true
+			}
+		}
+	}
+	return fields, structs, nil
+}
+
+func (b *fieldBuilder) fields(name string, node *columnNode, structs *[]*Struct) []*Field {
+	var fields []*Field
+	for _, child := range node.Children {
+		field := &Field{
+			Name:       b.fieldName(child.Key),
+			ColumnName: child.Key,
+		}
+		if child.Column != nil {
+			field.Type = b.typeName(child.Column.Type)
+		} else {
+			structName := name + flect.Pascalize(child.Key)
+			if child.List {
+				structName = name + flect.Pascalize(flect.Singularize(child.Key))
+			}
+			s := &Struct{Name: structName}
+			*structs = append(*structs, s)
+			s.Fields = b.fields(structName, child, structs)
+			field.Type = structName
+		}
+		if child.List {
+			field.Type = b.listOf(field.Type)
+		}
+		fields = append(fields, field)
+	}
+	return fields
 }
 
 type generator struct {
@@ -61,19 +135,20 @@ func (g *generator) Generate(sheet *Sheet) error {
 		columns = append(columns, c)
 	}
 
-	var fields []*Field
-	for _, column := range columns {
-		field := &Field{
-			Name:       strcase.ToCamel(column.Name),
-			Type:       column.Type.String(),
-			ColumnName: column.Name,
-		}
-		fields = append(fields, field)
+	builder := &fieldBuilder{
+		fieldName: strcase.ToCamel,
+		typeName:  ColumnType.String,
+		listOf:    func(t string) string { return "[]" + t },
+	}
+	fields, structs, err := builder.build(strcase.ToCamel(inflection.Singular(name)), columns, sheet.PrimaryKey)
+	if err != nil {
+		return errs.Wrap(err, "build fields")
 	}
 
 	data := map[string]any{
-		"Name":   strcase.ToCamel(inflection.Singular(name)),
-		"Fields": fields,
+		"Name":    strcase.ToCamel(inflection.Singular(name)),
+		"Fields":  fields,
+		"Structs": structs,
 	}
 	funcMap := map[string]any{
 		"camelize":    flect.Camelize,
@@ -117,22 +192,26 @@ func (g *goGenerator) Generate(sheet *Sheet) error {
 		columns = append(columns, c)
 	}
 
-	var fields []*Field
-	for _, column := range columns {
-		field := &Field{
-			Name:       flect.Pascalize(column.Name),
-			Type:       g.toGoType(column.Type),
-			ColumnName: column.Name,
-		}
-		fields = append(fields, field)
+	builder := &fieldBuilder{
+		fieldName: flect.Pascalize,
+		typeName:  g.toGoType,
+		listOf:    func(t string) string { return "[]" + t },
+	}
+	fields, structs, err := builder.build(flect.Pascalize(flect.Singularize(name)), columns, sheet.PrimaryKey)
+	if err != nil {
+		return errs.Wrap(err, "build fields")
 	}
 
 	imports := g.collectImports(fields)
+	for _, s := range structs {
+		imports = append(imports, g.collectImports(s.Fields)...)
+	}
 
 	data := map[string]any{
-		"Imports": imports,
+		"Imports": lo.Uniq(imports),
 		"Name":    flect.Pascalize(flect.Singularize(name)),
 		"Fields":  fields,
+		"Structs": structs,
 	}
 	funcMap := map[string]any{
 		"camelize":    flect.Camelize,
@@ -159,11 +238,57 @@ func (g *goGenerator) Generate(sheet *Sheet) error {
 	return nil
 }
 
+const goEnumTemplate = `// Code generated by exceref. DO NOT EDIT.
+
+package {{ .Package }}
+{{ range .Enums }}
+type {{ .Name }} string
+
+const (
+{{- $enum := .Name }}
+{{- range .Members }}
+	{{ $enum }}{{ .Name }} {{ $enum }} = {{ printf "%q" .Value }}
+{{- end }}
+)
+{{ end }}`
+
+func (g *goGenerator) GenerateEnums(enums []*Enum) error {
+	pkg := g.option.Package
+	if pkg == "" {
+		pkg = filepath.Base(g.option.OutDir)
+	}
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("package name:%s is not a valid identifier, set it with --package", pkg)
+	}
+	enumTypes, err := enumData(enums)
+	if err != nil {
+		return err
+	}
+	data := map[string]any{
+		"Package": pkg,
+		"Enums":   enumTypes,
+	}
+	tpl := template.Must(template.New("").Parse(goEnumTemplate))
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return errs.Wrap(err, "execute enum template")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errs.Wrap(err, "format generated enum source")
+	}
+	if err := os.WriteFile(filepath.Join(g.option.OutDir, g.option.Prefix+"enums.gen.go"), src, 0644); err != nil {
+		return errs.Wrap(err, "write generated go enum file")
+	}
+	return nil
+}
+
 func (g *goGenerator) collectImports(columns []*Field) []string {
 	importSet := make(map[string]struct{})
 
 	for _, c := range columns {
-		switch c.Type {
+		switch strings.TrimLeft(c.Type, "[]*") {
 		case "time.Time":
 			importSet["time"] = struct{}{}
 		case "civil.Date":
@@ -179,6 +304,18 @@ func (g *goGenerator) collectImports(columns []*Field) []string {
 }
 
 func (g *goGenerator) toGoType(t ColumnType) string {
+	if t.IsList() {
+		return "[]" + g.toGoType(t.Elem())
+	}
+	if t.IsNullable() {
+		return "*" + g.toGoType(t.NonNull())
+	}
+	if t.IsEnum() {
+		return identifier(t.EnumName())
+	}
+	if t.IsObject() {
+		return "*" + flect.Pascalize(flect.Singularize(g.option.Prefix+t.ObjectName()))
+	}
 	switch t {
 	case ColumnTypeString:
 		return "string"
@@ -218,19 +355,20 @@ func (g *csharpGenerator) Generate(sheet *Sheet) error {
 		columns = append(columns, c)
 	}
 
-	var fields []*Field
-	for _, column := range columns {
-		field := &Field{
-			Name:       strcase.ToCamel(column.Name),
-			Type:       g.toCsharpType(column.Type),
-			ColumnName: column.Name,
-		}
-		fields = append(fields, field)
+	builder := &fieldBuilder{
+		fieldName: strcase.ToCamel,
+		typeName:  g.toCsharpType,
+		listOf:    func(t string) string { return t + "[]" },
+	}
+	fields, structs, err := builder.build(strcase.ToCamel(inflection.Singular(name)), columns, sheet.PrimaryKey)
+	if err != nil {
+		return errs.Wrap(err, "build fields")
 	}
 
 	data := map[string]any{
-		"Name":   strcase.ToCamel(inflection.Singular(name)),
-		"Fields": fields,
+		"Name":    strcase.ToCamel(inflection.Singular(name)),
+		"Fields":  fields,
+		"Structs": structs,
 	}
 	funcMap := map[string]any{
 		"camelize":    flect.Camelize,
@@ -253,7 +391,52 @@ func (g *csharpGenerator) Generate(sheet *Sheet) error {
 	return nil
 }
 
+// csharpEnumTemplate keeps the member values, which the data is exported with, in EnumMember attributes.
+const csharpEnumTemplate = `// Code generated by exceref. DO NOT EDIT.
+using System.Runtime.Serialization;
+{{ range .Enums }}
+public enum {{ .Name }}
+{
+{{- range .Members }}
+    [EnumMember(Value = {{ csharpString .Value }})]
+    {{ .Name }},
+{{- end }}
+}
+{{ end }}`
+
+func (g *csharpGenerator) GenerateEnums(enums []*Enum) error {
+	enumTypes, err := enumData(enums)
+	if err != nil {
+		return err
+	}
+	data := map[string]any{
+		"Enums": enumTypes,
+	}
+	tpl := template.Must(template.New("").Funcs(map[string]any{"csharpString": csharpString}).Parse(csharpEnumTemplate))
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return errs.Wrap(err, "execute enum template")
+	}
+	if err := os.WriteFile(filepath.Join(g.option.OutDir, g.option.Prefix+"Enums.cs"), buf.Bytes(), 0644); err != nil {
+		return errs.Wrap(err, "write generated csharp enum file")
+	}
+	return nil
+}
+
 func (g *csharpGenerator) toCsharpType(t ColumnType) string {
+	if t.IsList() {
+		return g.toCsharpType(t.Elem()) + "[]"
+	}
+	if t.IsNullable() {
+		return g.toCsharpType(t.NonNull()) + "?"
+	}
+	if t.IsEnum() {
+		return identifier(t.EnumName())
+	}
+	if t.IsObject() {
+		return strcase.ToCamel(inflection.Singular(g.option.Prefix + t.ObjectName()))
+	}
 	switch t {
 	case ColumnTypeString:
 		return "string"
@@ -271,3 +454,91 @@ func (g *csharpGenerator) toCsharpType(t ColumnType) string {
 		return "object"
 	}
 }
+
+type enumType struct {
+	Name    string
+	Members []enumMember
+}
+
+type enumMember struct {
+	Name  string
+	Value string
+}
+
+// enumData names the enums and their members with identifiers. Enums or members of an enum whose identifiers
+// collide, such as SSR and ssr, are rejected.
+func enumData(enums []*Enum) ([]*enumType, error) {
+	data := make([]*enumType, len(enums))
+	names := make(map[string]string)
+	for i, enum := range enums {
+		name := identifier(enum.Name)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("enum:%s and enum:%s have the same identifier:%s", other, enum.Name, name)
+		}
+		names[name] = enum.Name
+
+		data[i] = &enumType{Name: name}
+		members := make(map[string]string)
+		for _, member := range enum.Members {
+			memberName := identifier(member)
+			if other, ok := members[memberName]; ok {
+				return nil, fmt.Errorf("enum:%s member:%s and member:%s have the same identifier:%s", enum.Name, other, member, memberName)
+			}
+			members[memberName] = member
+			data[i].Members = append(data[i].Members, enumMember{Name: memberName, Value: member})
+		}
+	}
+	return data, nil
+}
+
+// identifier pascalizes s into an exported identifier of go and csharp. Runes other than letters and digits
+// separate the words, and an identifier that does not start with an upper case letter, such as 1star or レア,
+// is prefixed with X.
+func identifier(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if isASCII(word) {
+			// flect keeps acronyms such as ID, but garbles other scripts.
+			b.WriteString(flect.Pascalize(word))
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	name := b.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// csharpString quotes s as a csharp string literal.
+func csharpString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04x", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -3,6 +3,7 @@ package exceref
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -69,3 +70,139 @@ type {{ .Name }} struct {
 	require.Contains(t, content, "ID        int64")
 	require.Contains(t, content, "CreatedAt time.Time")
 }
+
+func TestGoGenerator_toGoType(t *testing.T) {
+	t.Parallel()
+
+	g := NewGoGenerator(GenerateOption{})
+	require.Equal(t, "int64", g.toGoType(ColumnTypeInt))
+	require.Equal(t, "[]int64", g.toGoType("[]int"))
+	require.Equal(t, "[]string", g.toGoType("[]string(;)"))
+	require.Equal(t, "[]time.Time", g.toGoType("[]datetime"))
+	require.Equal(t, "*int64", g.toGoType("int?"))
+	require.Equal(t, "*time.Time", g.toGoType("datetime?"))
+	require.Equal(t, "*Weapon", g.toGoType(ObjectOf("weapons")))
+	require.Equal(t, "[]*MWeapon", NewGoGenerator(GenerateOption{Prefix: "m_"}).toGoType(ListOf(ObjectOf("weapons"), ",")))
+}
+
+func TestCsharpGenerator_toCsharpType(t *testing.T) {
+	t.Parallel()
+
+	g := NewCsharpGenerator(GenerateOption{})
+	require.Equal(t, "int", g.toCsharpType(ColumnTypeInt))
+	require.Equal(t, "int[]", g.toCsharpType("[]int"))
+	require.Equal(t, "double[]", g.toCsharpType("[]float(;)"))
+	require.Equal(t, "int?", g.toCsharpType("int?"))
+	require.Equal(t, "DateTime?", g.toCsharpType("datetime?"))
+	require.Equal(t, "Weapon", g.toCsharpType(ObjectOf("weapons")))
+	require.Equal(t, "MWeapon[]", NewCsharpGenerator(GenerateOption{Prefix: "m_"}).toCsharpType(ListOf(ObjectOf("weapons"), ",")))
+}
+
+func TestGoGenerator_GenerateEnums(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	g := NewGoGenerator(GenerateOption{OutDir: dir, Package: "models"})
+	require.NoError(t, g.GenerateEnums([]*Enum{
+		{Name: "item_rarity", Members: []string{"common", "super_rare"}},
+	}))
+
+	body, err := os.ReadFile(filepath.Join(dir, "enums.gen.go"))
+	require.NoError(t, err)
+	content := string(body)
+	require.Contains(t, content, "package models")
+	require.Contains(t, content, "type ItemRarity string")
+	require.Contains(t, content, `ItemRaritySuperRare ItemRarity = "super_rare"`)
+	require.Equal(t, "ItemRarity", g.toGoType("enum:item_rarity"))
+}
+
+func TestGoGenerator_GenerateEnumsPackage(t *testing.T) {
+	t.Parallel()
+
+	enums := []*Enum{{Name: "rarity", Members: []string{"common"}}}
+	dir := filepath.Join(t.TempDir(), "out-go")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	require.EqualError(t, NewGoGenerator(GenerateOption{OutDir: dir}).GenerateEnums(enums),
+		"package name:out-go is not a valid identifier, set it with --package")
+	require.Error(t, NewGoGenerator(GenerateOption{OutDir: "."}).GenerateEnums(enums))
+	require.NoError(t, NewGoGenerator(GenerateOption{OutDir: dir, Package: "models"}).GenerateEnums(enums))
+}
+
+func TestGenerateEnums_Identifiers(t *testing.T) {
+	t.Parallel()
+
+	enums := []*Enum{{Name: "rarity", Members: []string{"レア", "1star", `say "hi"`, "user_id"}}}
+
+	dir := t.TempDir()
+	require.NoError(t, NewGoGenerator(GenerateOption{OutDir: dir, Package: "models"}).GenerateEnums(enums))
+	body, err := os.ReadFile(filepath.Join(dir, "enums.gen.go"))
+	require.NoError(t, err)
+	// gofmt aligns the constants, so the spaces are collapsed before matching.
+	content := strings.Join(strings.Fields(string(body)), " ")
+	require.Contains(t, content, `RarityXレア Rarity = "レア"`)
+	require.Contains(t, content, `RarityX1star Rarity = "1star"`)
+	require.Contains(t, content, `RaritySayHi Rarity = "say \"hi\""`)
+	require.Contains(t, content, `RarityUserID Rarity = "user_id"`)
+
+	require.NoError(t, NewCsharpGenerator(GenerateOption{OutDir: dir}).GenerateEnums(enums))
+	body, err = os.ReadFile(filepath.Join(dir, "Enums.cs"))
+	require.NoError(t, err)
+	content = string(body)
+	require.Contains(t, content, "[EnumMember(Value = \"レア\")]\n    Xレア,")
+	require.Contains(t, content, "[EnumMember(Value = \"1star\")]\n    X1star,")
+	require.Contains(t, content, "[EnumMember(Value = \"say \\\"hi\\\"\")]\n    SayHi,")
+}
+
+func TestGenerateEnums_Collision(t *testing.T) {
+	t.Parallel()
+
+	g := NewGoGenerator(GenerateOption{OutDir: t.TempDir(), Package: "models"})
+	require.EqualError(t, g.GenerateEnums([]*Enum{{Name: "rarity", Members: []string{"SSR", "ssr"}}}),
+		"enum:rarity member:SSR and member:ssr have the same identifier:Ssr")
+	require.EqualError(t, g.GenerateEnums([]*Enum{{Name: "rarity"}, {Name: "Rarity"}}),
+		"enum:rarity and enum:Rarity have the same identifier:Rarity")
+}
+
+func TestGoGenerator_GenerateNested(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "model.tmpl")
+	require.NoError(t, os.WriteFile(templatePath, []byte(`package models
+
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }} `+"`json:\"{{ .ColumnName }}\"`"+`
+{{- end }}
+}
+{{ range .Structs }}
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }} `+"`json:\"{{ .ColumnName }}\"`"+`
+{{- end }}
+}
+{{ end }}`), 0644))
+
+	g := NewGoGenerator(GenerateOption{OutDir: dir, TemplatePath: templatePath})
+	sheet := &Sheet{
+		Name: "items",
+		Columns: []*Column{
+			{Name: "id", Type: ColumnTypeInt, Index: 0},
+			{Name: "reward.item_id", Type: ColumnTypeInt, Index: 1},
+			{Name: "drops[0].at", Type: ColumnTypeDatetime, Index: 2},
+			{Name: "drops[1].at", Type: ColumnTypeDatetime, Index: 3},
+		},
+	}
+
+	require.NoError(t, g.Generate(sheet))
+
+	body, err := os.ReadFile(filepath.Join(dir, "items.gen.go"))
+	require.NoError(t, err)
+	content := string(body)
+	require.Contains(t, content, "Reward ItemReward `json:\"reward\"`")
+	require.Contains(t, content, "Drops  []ItemDrop `json:\"drops\"`")
+	require.Contains(t, content, "type ItemReward struct")
+	require.Contains(t, content, "ItemID int64 `json:\"item_id\"`")
+	require.Contains(t, content, "type ItemDrop struct")
+	require.Contains(t, content, "At time.Time `json:\"at\"`")
+}
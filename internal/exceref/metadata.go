@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,6 +19,8 @@ type MetadataColumnSchema struct {
 	Name        string           `yaml:"name"`
 	Type        ColumnType       `yaml:"type"`
 	DisplayName string           `yaml:"display_name"`
+	PrimaryKey  bool             `yaml:"primary_key,omitempty"`
+	Targets     []string         `yaml:"targets,omitempty"`
 	Ref         *MetadataRefSpec `yaml:"ref,omitempty"`
 }
 
@@ -40,6 +43,8 @@ type MetadataReference struct {
 	ReferenceKey   string `yaml:"reference_key"`
 	ReferenceValue string `yaml:"reference_value"`
 	ReferenceName  string `yaml:"reference_name"`
+	Embed          string `yaml:"embed,omitempty"`
+	KeepKeyAs      string `yaml:"keep_key_as,omitempty"`
 }
 
 func NewMetadataExporter(outDir string) *metadataExporter {
@@ -68,6 +73,8 @@ func (e *metadataExporter) Export(file *File) error {
 			ReferenceKey:   definition.ReferenceKey,
 			ReferenceValue: definition.ReferenceValue,
 			ReferenceName:  definition.ReferenceName,
+			Embed:          definition.Embed,
+			KeepKeyAs:      definition.KeepKeyAs,
 		})
 	}
 
@@ -75,10 +82,10 @@ func (e *metadataExporter) Export(file *File) error {
 	for _, name := range file.xlsx.GetSheetMap() {
 		dataYaml := &MetadataDataYAML{}
 		switch {
-		case strings.HasPrefix(name, "_") && name != "_types":
+		case strings.HasPrefix(name, "_") && name != TypeDefinitionSheetName:
 			continue
-		case name == "_types":
-			dataYaml.Sheet = file.Name() + "_types"
+		case name == TypeDefinitionSheetName:
+			dataYaml.Sheet = file.Name() + TypeDefinitionSheetName
 		default:
 			dataYaml.Sheet = name
 		}
@@ -92,10 +99,12 @@ func (e *metadataExporter) Export(file *File) error {
 				Name:        col.Name,
 				Type:        col.Type,
 				DisplayName: col.Description,
+				PrimaryKey:  lo.Contains(sheet.PrimaryKey, col),
+				Targets:     col.Targets,
 			}
-			if col.Type == ColumnTypeRef {
+			if col.Type.Elem().NonNull() == ColumnTypeRef {
 				for _, reference := range referencesYaml.References {
-					if !(sheet.Name == reference.Sheet && col.Name == reference.Column) {
+					if !(sheet.Name == reference.Sheet && col.Name == lo.FirstOrEmpty(splitReferenceColumns(reference.Column))) {
 						continue
 					}
 					schema.Ref = &MetadataRefSpec{
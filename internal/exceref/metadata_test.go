@@ -17,7 +17,7 @@ func TestMetadataExporter_Export(t *testing.T) {
 	bookPath := filepath.Join(dir, "book.xlsx")
 	buildMetadataTestBook(t, bookPath)
 
-	file, err := Open(bookPath)
+	file, err := Open(bookPath, OpenOption{})
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		require.NoError(t, file.Close())
@@ -0,0 +1,258 @@
+package exceref
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
+)
+
+// Project is a set of workbooks built together in one process. The workbooks share one reader, so that every
+// referenced file is opened and resolved once, and are ordered so that referenced workbooks come first.
+type Project struct {
+	reader *XLSXReader
+	files  []*File
+	sheets []*Sheet
+	// failures holds why workbooks could not be ordered. Those workbooks are left out of files.
+	failures []error
+}
+
+// FindWorkbooks expands directories and glob patterns into workbook paths. A directory stands for the workbooks
+// directly under it. Excel lock files, whose names start with ~$, are skipped. Errors name workbooks by their
+// file names, so workbooks sharing a file name in different directories are rejected.
+func FindWorkbooks(patterns []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	names := make(map[string]string)
+	for _, pattern := range patterns {
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "*.xlsx")
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errs.Wrap(err, "glob workbooks")
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no workbook matches: %s", pattern)
+		}
+		for _, match := range matches {
+			match = filepath.Clean(match)
+			if strings.HasPrefix(filepath.Base(match), "~$") || seen[match] {
+				continue
+			}
+			if other, ok := names[filepath.Base(match)]; ok {
+				return nil, fmt.Errorf("workbooks %s and %s share the file name %s", other, match, filepath.Base(match))
+			}
+			seen[match] = true
+			names[filepath.Base(match)] = match
+			paths = append(paths, match)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func OpenProject(paths []string, option OpenOption) (*Project, error) {
+	project := &Project{reader: &XLSXReader{option: option, file: make(map[string]*File)}}
+
+	files := make(map[string]*File)
+	for _, path := range paths {
+		file, err := project.reader.open(path)
+		if err != nil {
+			project.Close()
+			return nil, err
+		}
+		files[filepath.Clean(path)] = file
+	}
+	project.files, project.failures = sortWorkbooks(paths, files)
+	return project, nil
+}
+
+// Files returns the workbooks of the project in dependency order, leaving out the ones that could not be ordered.
+func (p *Project) Files() []*File {
+	return p.files
+}
+
+// Close closes every workbook opened for the project, including referenced workbooks outside of it.
+func (p *Project) Close() error {
+	var failures []error
+	for _, file := range p.reader.file {
+		if err := file.Close(); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return errors.Join(failures...)
+}
+
+func (p *Project) Export(exporter Exporter, filter SheetFilter) error {
+	sheets, err := p.resolvedDataSheets()
+	if err != nil {
+		return errs.Wrap(err, "load export target sheets")
+	}
+	for _, sheet := range filter.Select(sheets) {
+		if err := exporter.Export(sheet); err != nil {
+			return errs.Wrap(err, "export sheet")
+		}
+	}
+	return nil
+}
+
+func (p *Project) Generate(generator Generator, filter SheetFilter) error {
+	sheets, err := p.resolvedDataSheets()
+	if err != nil {
+		return errs.Wrap(err, "load generate target sheets")
+	}
+
+	if g, ok := generator.(EnumGenerator); ok {
+		enums, err := p.Enums()
+		if err != nil {
+			return errs.Wrap(err, "load enums")
+		}
+		if len(enums) > 0 {
+			if err := g.GenerateEnums(enums); err != nil {
+				return errs.Wrap(err, "generate enums")
+			}
+		}
+	}
+
+	for _, sheet := range filter.Select(sheets) {
+		if err := generator.Generate(sheet); err != nil {
+			return errs.Wrap(err, "generate code")
+		}
+	}
+	return nil
+}
+
+// Check loads, validates and resolves every data sheet without writing anything, and returns all failures.
+func (p *Project) Check() error {
+	_, err := p.resolvedDataSheets()
+	return err
+}
+
+// Enums returns the enums of every workbook. An enum name must be defined by one workbook only.
+func (p *Project) Enums() ([]*Enum, error) {
+	var enums []*Enum
+	owners := make(map[string]*File)
+	for _, file := range p.files {
+		fileEnums, err := file.Enums()
+		if err != nil {
+			return nil, errs.Wrap(err, "load enums")
+		}
+		for _, enum := range fileEnums {
+			if owner, ok := owners[enum.Name]; ok {
+				return nil, fmt.Errorf("enum:%s is defined in both %s and %s", enum.Name, filepath.Base(owner.path), filepath.Base(file.path))
+			}
+			owners[enum.Name] = file
+			enums = append(enums, enum)
+		}
+	}
+	return enums, nil
+}
+
+// resolvedDataSheets loads, validates and resolves the data sheets of every workbook once, and reports the failures
+// of all workbooks together. Sheet names must be unique across the project since they name the outputs.
+func (p *Project) resolvedDataSheets() ([]*Sheet, error) {
+	if p.sheets != nil {
+		return p.sheets, nil
+	}
+	var sheets []*Sheet
+	failures := append([]error{}, p.failures...)
+	owners := make(map[string]*File)
+	for _, file := range p.files {
+		fileSheets, err := file.resolvedDataSheets()
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		for _, sheet := range fileSheets {
+			if owner, ok := owners[sheet.Name]; ok {
+				failures = append(failures, fmt.Errorf("sheet:%s is defined in both %s and %s", sheet.Name, filepath.Base(owner.path), filepath.Base(file.path)))
+				continue
+			}
+			owners[sheet.Name] = file
+			sheets = append(sheets, sheet)
+		}
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	p.sheets = sheets
+	return p.sheets, nil
+}
+
+// dependencies returns the paths of the workbooks referenced by the workbook, other than itself.
+func (f *File) dependencies() ([]string, error) {
+	resolver, err := f.ReferenceResolver()
+	if err != nil {
+		return nil, errs.Wrap(err, "load reference resolver")
+	}
+	var paths []string
+	for _, definition := range resolver.ReferenceDefinitions {
+		path := filepath.Clean(definition.ReferenceFilePath())
+		if _, ok := NewFileReader(path, f.option); ok || definition.SameWorkbook() || path == filepath.Clean(f.path) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// sortWorkbooks orders the workbooks so that every workbook comes after the workbooks it references.
+// Workbooks outside of the project are not ordered. A workbook whose references cannot be loaded, or which is
+// part of a cycle between workbooks, is left out and reported, and the others are ordered as usual.
+func sortWorkbooks(paths []string, files map[string]*File) ([]*File, []error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	failed := make(map[string]bool)
+	var ordered []*File
+	var failures []error
+	var stack []string
+
+	var visit func(path string)
+	visit = func(path string) {
+		switch state[path] {
+		case visiting:
+			cycle := append(append([]string{}, stack[lo.IndexOf(stack, path):]...), path)
+			failures = append(failures, fmt.Errorf("workbook reference cycle detected: %s", strings.Join(lo.Map(cycle, func(p string, _ int) string {
+				return filepath.Base(p)
+			}), " -> ")))
+			for _, p := range cycle {
+				failed[p] = true
+			}
+			return
+		case visited:
+			return
+		}
+		state[path] = visiting
+		stack = append(stack, path)
+
+		dependencies, err := files[path].dependencies()
+		if err != nil {
+			failures = append(failures, errs.Wrap(errs.WithFile(err, filepath.Base(path)), "load dependencies"))
+			failed[path] = true
+		}
+		for _, dependency := range dependencies {
+			if _, ok := files[dependency]; ok {
+				visit(dependency)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[path] = visited
+		if !failed[path] {
+			ordered = append(ordered, files[path])
+		}
+	}
+	for _, path := range paths {
+		visit(filepath.Clean(path))
+	}
+	return ordered, failures
+}
@@ -0,0 +1,211 @@
+package exceref_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// writeProjectBook writes a workbook with one data sheet and the given reference definitions.
+func writeProjectBook(t *testing.T, path, sheet string, rows [][]any, references [][]any) {
+	t.Helper()
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", sheet))
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, []int{1, 2, 4, 5}[i])
+		require.NoError(t, err)
+		require.NoError(t, book.SetSheetRow(sheet, cell, &row))
+	}
+	_, err := book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "reference_value"}))
+	for i, reference := range references {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		require.NoError(t, err)
+		require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, cell, &reference))
+	}
+	require.NoError(t, book.SaveAs(path))
+	require.NoError(t, book.Close())
+}
+
+func TestFindWorkbooks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.xlsx", "a.xlsx", "~$a.xlsx", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	paths, err := exceref.FindWorkbooks([]string{dir, filepath.Join(dir, "a.*")})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a.xlsx"), filepath.Join(dir, "b.xlsx")}, paths)
+
+	_, err = exceref.FindWorkbooks([]string{filepath.Join(dir, "*.xlsm")})
+	require.Error(t, err)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "a.xlsx"), nil, 0o644))
+	_, err = exceref.FindWorkbooks([]string{dir, filepath.Join(dir, "sub")})
+	require.EqualError(t, err, fmt.Sprintf("workbooks %s and %s share the file name a.xlsx",
+		filepath.Join(dir, "a.xlsx"), filepath.Join(dir, "sub", "a.xlsx")))
+}
+
+func TestProject_Export(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProjectBook(t, filepath.Join(dir, "units.xlsx"), "units",
+		[][]any{{"string", "ref"}, {"name", "item"}, {"knight", "sword"}},
+		[][]any{{"units", "item", "items", "items", "code", "id"}})
+	writeProjectBook(t, filepath.Join(dir, "items.xlsx"), "items",
+		[][]any{{"string", "int"}, {"code", "id"}, {"sword", 1}}, nil)
+
+	paths, err := exceref.FindWorkbooks([]string{dir})
+	require.NoError(t, err)
+	project, err := exceref.OpenProject(paths, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, project.Close())
+	})
+	require.Equal(t, []string{"items", "units"}, lo.Map(project.Files(), func(f *exceref.File, _ int) string {
+		return f.Name()
+	}))
+
+	require.NoError(t, project.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"item":1,"name":"knight"}]`+"\n", string(body))
+	body, err = os.ReadFile(filepath.Join(dir, "items.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"code":"sword","id":1}]`+"\n", string(body))
+}
+
+func TestProject_Export_ChainedReference(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProjectBook(t, filepath.Join(dir, "units.xlsx"), "units",
+		[][]any{{"string", "ref"}, {"name", "weapon"}, {"knight", "sword"}},
+		[][]any{{"units", "weapon", "weapons", "weapons", "code", "element"}})
+	writeProjectBook(t, filepath.Join(dir, "weapons.xlsx"), "weapons",
+		[][]any{{"string", "ref"}, {"code", "element"}, {"sword", "fire"}},
+		[][]any{{"weapons", "element", "elements", "elements", "code", "id"}})
+	writeProjectBook(t, filepath.Join(dir, "elements.xlsx"), "elements",
+		[][]any{{"string", "int"}, {"code", "id"}, {"fire", 1}}, nil)
+
+	// weapons is resolved before units in a project, which must not change what units reads from it.
+	single := t.TempDir()
+	file, err := exceref.Open(filepath.Join(dir, "units.xlsx"), exceref.OpenOption{})
+	require.NoError(t, err)
+	require.NoError(t, file.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: single}), exceref.SheetFilter{}))
+	require.NoError(t, file.Close())
+
+	paths, err := exceref.FindWorkbooks([]string{dir})
+	require.NoError(t, err)
+	project, err := exceref.OpenProject(paths, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, project.Close())
+	})
+	require.NoError(t, project.Export(exceref.NewJSONExporter(exceref.ExportOption{OutDir: dir}), exceref.SheetFilter{}))
+
+	expected, err := os.ReadFile(filepath.Join(single, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"name":"knight","weapon":"fire"}]`+"\n", string(expected))
+	body, err := os.ReadFile(filepath.Join(dir, "units.json"))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(body))
+	body, err = os.ReadFile(filepath.Join(dir, "weapons.json"))
+	require.NoError(t, err)
+	require.Equal(t, `[{"code":"sword","element":1}]`+"\n", string(body))
+}
+
+func TestProject_Cycle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProjectBook(t, filepath.Join(dir, "a.xlsx"), "as",
+		[][]any{{"string", "ref"}, {"code", "b"}},
+		[][]any{{"as", "b", "b", "bs", "code", "code"}})
+	writeProjectBook(t, filepath.Join(dir, "b.xlsx"), "bs",
+		[][]any{{"string", "ref"}, {"code", "a"}},
+		[][]any{{"bs", "a", "a", "as", "code", "code"}})
+	writeProjectBook(t, filepath.Join(dir, "c.xlsx"), "cs",
+		[][]any{{"string", "ref"}, {"code", "a"}},
+		[][]any{{"cs", "a,code", "a", "as", "code", "code"}})
+	writeProjectBook(t, filepath.Join(dir, "d.xlsx"), "ds",
+		[][]any{{"string"}, {"code"}, {"d1"}}, nil)
+
+	paths, err := exceref.FindWorkbooks([]string{dir})
+	require.NoError(t, err)
+	project, err := exceref.OpenProject(paths, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, project.Close())
+	})
+
+	// The cycle and the broken reference definitions are reported together, and the other workbooks are still checked.
+	require.Equal(t, []string{"d"}, lo.Map(project.Files(), func(f *exceref.File, _ int) string {
+		return f.Name()
+	}))
+	require.Equal(t, []string{
+		"workbook reference cycle detected: a.xlsx -> b.xlsx -> a.xlsx",
+		"c.xlsx:_references!2: column(a,code) and reference_key(code) must have the same number of columns",
+	}, lo.Map(errs.Flatten(project.Check()), func(e error, _ int) string {
+		return e.Error()
+	}))
+}
+
+func TestProject_Check_EmbeddedWorkbookFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProjectBook(t, filepath.Join(dir, "b.xlsx"), "ys",
+		[][]any{{"string", "ref"}, {"code", "item"}, {"y1", "axe"}},
+		[][]any{{"ys", "item", "b", "ys", "code", "code"}})
+
+	book := excelize.NewFile()
+	require.NoError(t, book.SetSheetName("Sheet1", "xs"))
+	require.NoError(t, book.SetSheetRow("xs", "A1", &[]any{"string", "ref"}))
+	require.NoError(t, book.SetSheetRow("xs", "A2", &[]any{"code", "y"}))
+	require.NoError(t, book.SetSheetRow("xs", "A4", &[]any{"x1", "y1"}))
+	_, err := book.NewSheet(exceref.ReferenceDefinitionSheetName)
+	require.NoError(t, err)
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A1",
+		&[]any{"sheet", "column", "reference_file", "reference_sheet", "reference_key", "embed"}))
+	require.NoError(t, book.SetSheetRow(exceref.ReferenceDefinitionSheetName, "A2", &[]any{"xs", "y", "b", "ys", "code", "*"}))
+	require.NoError(t, book.SaveAs(filepath.Join(dir, "a.xlsx")))
+	require.NoError(t, book.Close())
+
+	messages := func(err error) []string {
+		return lo.Map(errs.Flatten(err), func(e error, _ int) string {
+			return e.Error()
+		})
+	}
+	expected := []string{"b.xlsx:ys!B4: column:item reference:axe value not found from ys:code"}
+
+	// b.xlsx is outside of the project, so its failure is only reached through the embed reference.
+	project, err := exceref.OpenProject([]string{filepath.Join(dir, "a.xlsx")}, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, project.Close())
+	})
+	require.Equal(t, expected, messages(project.Check()))
+
+	// b.xlsx is resolved first and its failure is returned again to the embed reference of a.xlsx.
+	project, err = exceref.OpenProject([]string{filepath.Join(dir, "a.xlsx"), filepath.Join(dir, "b.xlsx")}, exceref.OpenOption{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, project.Close())
+	})
+	require.Equal(t, expected, messages(project.Check()))
+}
@@ -0,0 +1,214 @@
+package exceref
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// NewFileReader returns the reader of a reference file that is not a workbook, chosen by its extension.
+// It returns false for workbooks and unknown extensions.
+func NewFileReader(path string, option OpenOption) (SheetReader, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return NewCSVReader(',', option), true
+	case ".tsv":
+		return NewCSVReader('\t', option), true
+	case ".json":
+		return NewJSONReader(option), true
+	case ".yaml", ".yml":
+		return NewYAMLReader(option), true
+	}
+	return nil, false
+}
+
+// NewCSVReader returns a reader of delimited text files, which hold a single sheet in the data sheet layout:
+// a type row, a name row and a description row followed by the data. The files have no _settings sheet, and are
+// parsed with the default settings of option for the sheet name, since several workbooks may share a file.
+func NewCSVReader(comma rune, option OpenOption) *CSVReader {
+	return &CSVReader{comma: comma, option: option, sheets: make(map[string]*Sheet)}
+}
+
+type CSVReader struct {
+	comma  rune
+	option OpenOption
+	sheets map[string]*Sheet
+}
+
+// Open reads the file at path. The sheet name only names the returned sheet, since the file has one sheet.
+func (r *CSVReader) Open(path string, sheet string) (*Sheet, error) {
+	path = filepath.Clean(path)
+	if s, ok := r.sheets[path]; ok {
+		return s, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errs.Wrap(err, "open reference file")
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comma = r.comma
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errs.Wrap(err, "read csv")
+	}
+	option, err := r.option.Settings.SheetOption(sheet, r.option.Location)
+	if err != nil {
+		return nil, errs.Wrap(err, "build sheet option")
+	}
+	s, err := NewDataSeet(sheet, rows, option)
+	if err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(path)), "parse data sheet")
+	}
+	r.sheets[path] = s
+	return s, nil
+}
+
+// NewJSONReader returns a reader of JSON files holding an array of flat objects, like the json export.
+func NewJSONReader(option OpenOption) *RecordReader {
+	return &RecordReader{decode: json.Unmarshal, option: option, sheets: make(map[string]*Sheet)}
+}
+
+// NewYAMLReader returns a reader of YAML files holding a sequence of flat mappings, like the yaml export.
+func NewYAMLReader(option OpenOption) *RecordReader {
+	return &RecordReader{decode: yaml.Unmarshal, option: option, sheets: make(map[string]*Sheet)}
+}
+
+// RecordReader reads a list of records into a sheet. Columns are the keys of the records in alphabetical order,
+// and their types are inferred from the values.
+type RecordReader struct {
+	decode func([]byte, any) error
+	option OpenOption
+	sheets map[string]*Sheet
+}
+
+// Open reads the file at path. The sheet name only names the returned sheet, since the file has one sheet.
+func (r *RecordReader) Open(path string, sheet string) (*Sheet, error) {
+	path = filepath.Clean(path)
+	if s, ok := r.sheets[path]; ok {
+		return s, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrap(err, "open reference file")
+	}
+	var records []map[string]any
+	if err := r.decode(b, &records); err != nil {
+		return nil, errs.Wrap(err, "decode records")
+	}
+	rows, err := recordRows(records)
+	if err != nil {
+		return nil, errs.Wrap(&errs.CellError{File: filepath.Base(path), Sheet: sheet, Err: err}, "build rows")
+	}
+	s, err := NewDataSeet(sheet, rows, SheetOption{Location: r.option.Location})
+	if err != nil {
+		return nil, errs.Wrap(errs.WithFile(err, filepath.Base(path)), "parse data sheet")
+	}
+	r.sheets[path] = s
+	return s, nil
+}
+
+// recordRows lays out the records as the rows of a data sheet.
+func recordRows(records []map[string]any) ([][]string, error) {
+	var names []string
+	types := make(map[string]ColumnType)
+	nullable := make(map[string]bool)
+	for i, record := range records {
+		for name, value := range record {
+			if _, ok := types[name]; !ok {
+				names = append(names, name)
+				types[name] = ""
+			}
+			if value == nil {
+				nullable[name] = true
+				continue
+			}
+			columnType, err := recordValueType(value)
+			if err != nil {
+				return nil, fmt.Errorf("record:%d key:%s %w", i+1, name, err)
+			}
+			types[name] = mergeRecordType(types[name], columnType)
+		}
+	}
+	sort.Strings(names)
+
+	typeRow := make([]string, len(names))
+	for j, name := range names {
+		columnType := types[name]
+		if columnType == "" {
+			columnType = ColumnTypeString
+		}
+		for _, record := range records {
+			if _, ok := record[name]; !ok {
+				nullable[name] = true
+			}
+		}
+		if nullable[name] {
+			columnType = columnType.Nullable()
+		}
+		typeRow[j] = columnType.String()
+	}
+	rows := [][]string{typeRow, names, make([]string, len(names))}
+	for _, record := range records {
+		row := make([]string, len(names))
+		for j, name := range names {
+			row[j] = recordRaw(record[name])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func recordValueType(value any) (ColumnType, error) {
+	switch v := value.(type) {
+	case string:
+		return ColumnTypeString, nil
+	case bool:
+		return ColumnTypeBool, nil
+	case int, int64, uint64:
+		return ColumnTypeInt, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return ColumnTypeInt, nil
+		}
+		return ColumnTypeFloat, nil
+	case time.Time:
+		return ColumnTypeDatetime, nil
+	}
+	return "", fmt.Errorf("unsupported value:%v", value)
+}
+
+// mergeRecordType returns the type that holds the values of both types.
+func mergeRecordType(a, b ColumnType) ColumnType {
+	switch {
+	case a == "" || a == b:
+		return b
+	case a == ColumnTypeInt && b == ColumnTypeFloat, a == ColumnTypeFloat && b == ColumnTypeInt:
+		return ColumnTypeFloat
+	}
+	return ColumnTypeString
+}
+
+func recordRaw(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
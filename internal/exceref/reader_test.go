@@ -0,0 +1,109 @@
+package exceref_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/daichirata/exceref/internal/exceref"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReader_CSV(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{name: "items.csv", body: "string,int\ncode,id\nname,id\nsword,1\nshield,2\n"},
+		{name: "items.tsv", body: "string\tint\ncode\tid\nname\tid\nsword\t1\nshield\t2\n"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.name)
+			require.NoError(t, os.WriteFile(path, []byte(tt.body), 0o644))
+
+			reader, ok := exceref.NewFileReader(path, exceref.OpenOption{})
+			require.True(t, ok)
+			sheet, err := reader.Open(path, "items")
+			require.NoError(t, err)
+			require.Equal(t, []map[string]any{
+				{"code": "sword", "id": 1},
+				{"code": "shield", "id": 2},
+			}, sheet.Map())
+		})
+	}
+}
+
+func TestFileReader_CSVSettings(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "items.csv")
+	require.NoError(t, os.WriteFile(path, []byte("code,opened_at\nstring,datetime\nname,opened\n// old,\nsword,2024/01/02\n"), 0o644))
+
+	settings, err := exceref.NewDefaultSettings(map[string]string{
+		"type_row":       "2",
+		"name_row":       "1",
+		"comment_marker": "//",
+		"time_layouts":   "2006/01/02",
+	})
+	require.NoError(t, err)
+	reader, ok := exceref.NewFileReader(path, exceref.OpenOption{Settings: settings})
+	require.True(t, ok)
+	sheet, err := reader.Open(path, "items")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"code": "sword", "opened_at": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}, sheet.Map())
+}
+
+func TestFileReader_Records(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{name: "items.json", body: `[{"code":"sword","id":1,"rate":0.5},{"code":"shield","id":2,"rate":1,"note":"big"}]`},
+		{name: "items.yaml", body: "- {code: sword, id: 1, rate: 0.5}\n- {code: shield, id: 2, rate: 1, note: big}\n"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.name)
+			require.NoError(t, os.WriteFile(path, []byte(tt.body), 0o644))
+
+			reader, ok := exceref.NewFileReader(path, exceref.OpenOption{})
+			require.True(t, ok)
+			sheet, err := reader.Open(path, "items")
+			require.NoError(t, err)
+			require.Equal(t, []exceref.ColumnType{"string", "int", "string?", "float"}, []exceref.ColumnType{
+				sheet.Columns[0].Type, sheet.Columns[1].Type, sheet.Columns[2].Type, sheet.Columns[3].Type,
+			})
+			require.Equal(t, []map[string]any{
+				{"code": "sword", "id": 1, "note": nil, "rate": 0.5},
+				{"code": "shield", "id": 2, "note": "big", "rate": float64(1)},
+			}, sheet.Map())
+		})
+	}
+}
+
+func TestFileReader_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, ok := exceref.NewFileReader("items.xlsx", exceref.OpenOption{})
+	require.False(t, ok)
+
+	path := filepath.Join(t.TempDir(), "items.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"code":"sword","tags":["a"]}]`), 0o644))
+	reader, ok := exceref.NewFileReader(path, exceref.OpenOption{})
+	require.True(t, ok)
+	_, err := reader.Open(path, "items")
+	require.EqualError(t, errs.Flatten(err)[0], "items.json:items: record:1 key:tags unsupported value:[a]")
+}
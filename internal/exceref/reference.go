@@ -1,11 +1,13 @@
 package exceref
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
 )
 
 type ReferenceDefinition struct {
@@ -18,6 +20,17 @@ type ReferenceDefinition struct {
 	ReferenceKey   string
 	ReferenceValue string
 	ReferenceName  string
+	// Embed resolves the reference to the referenced row as an object instead of the reference_value cell.
+	// It is either * for every column or a comma separated list of columns.
+	Embed string
+	// KeepKeyAs adds a column of that name holding the keys as written, next to the resolved values.
+	KeepKeyAs string
+}
+
+// SameWorkbook reports whether the reference points at a sheet of the workbook defining it,
+// which is written as an empty reference_file or ".".
+func (r *ReferenceDefinition) SameWorkbook() bool {
+	return r.ReferenceFile == "" || r.ReferenceFile == "."
 }
 
 func (r *ReferenceDefinition) ReferenceFileName() string {
@@ -32,20 +45,149 @@ func (r *ReferenceDefinition) ReferenceFilePath() string {
 }
 
 func (r *ReferenceDefinition) PolymorphicReference() bool {
-	return r.ReferenceValue == ""
+	return r.ReferenceValue == "" && !r.EmbedReference()
+}
+
+func (r *ReferenceDefinition) EmbedReference() bool {
+	return r.Embed != ""
+}
+
+// EmbedColumns returns the columns embedded by the reference, or nil for every column.
+func (r *ReferenceDefinition) EmbedColumns() []string {
+	if r.Embed == EmbedAllColumns {
+		return nil
+	}
+	return splitReferenceColumns(r.Embed)
+}
+
+// Columns returns the referencing columns. Several comma separated columns form a composite key, whose value
+// is resolved into the first column.
+func (r *ReferenceDefinition) Columns() []string {
+	return splitReferenceColumns(r.Column)
+}
+
+// ReferenceKeys returns the key columns of the reference sheet. Several comma separated columns form a composite key.
+func (r *ReferenceDefinition) ReferenceKeys() []string {
+	return splitReferenceColumns(r.ReferenceKey)
+}
+
+// CompositeReference reports whether the reference is keyed by several columns.
+func (r *ReferenceDefinition) CompositeReference() bool {
+	return len(r.ReferenceKeys()) > 1
+}
+
+func splitReferenceColumns(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return lo.Map(strings.Split(s, ","), func(s string, _ int) string {
+		return strings.TrimSpace(s)
+	})
 }
 
+// EmbedAllColumns is the embed value that embeds every column of the referenced row.
+const EmbedAllColumns = "*"
+
 type Reference struct {
-	Definition  *ReferenceDefinition
-	KeyColumn   *Column
+	Definition *ReferenceDefinition
+	// Sheet is the reference sheet. Embed references read the referenced rows from it once it is resolved.
+	Sheet     *Sheet
+	KeyColumn *Column
+	// KeyColumns holds every key column of a composite reference. KeyColumn is the first of them.
+	KeyColumns  []*Column
 	ValueColumn *Column
-	Keys        []*Cell
-	Values      []*Cell
-	ValueMap    map[string]*Cell
+	// Keys holds the key of each row. The key of a composite reference joins the values with CompositeKeySeparator.
+	Keys   []*Cell
+	Values []*Cell
+	// ValueMap holds the values by key. The key of a composite reference is encoded by encodeKey.
+	ValueMap map[string]*Cell
+
+	rowIndexes map[string]int
+	embedded   map[string]map[string]any
+	// joinedKeys holds the keys of a composite reference by their joined form, which may stand for several keys.
+	joinedKeys map[string][]string
+}
+
+// ValueType returns the type of the referenced values, which is an object type for an embed reference.
+func (r *Reference) ValueType() ColumnType {
+	if r.Definition.EmbedReference() {
+		return ObjectOf(r.Sheet.Name)
+	}
+	return r.ValueColumn.Type
+}
+
+// Lookup returns the cell referenced by the key. The cell of an embed reference holds the referenced row
+// as a map of the embedded columns.
+func (r *Reference) Lookup(key string) (*Cell, bool) {
+	v, ok := r.ValueMap[key]
+	if !ok || !r.Definition.EmbedReference() {
+		return v, ok
+	}
+	m, ok := r.embedded[key]
+	if !ok {
+		m = r.embedRow(r.Sheet, key)
+		r.embedded[key] = m
+	}
+	return &Cell{Column: r.ValueColumn, Value: m, Raw: key}, true
+}
+
+// keyOf returns the key of the rows matching a key written in a single cell, which joins the values of a
+// composite key with CompositeKeySeparator. It fails if the joined key stands for the keys of several rows.
+func (r *Reference) keyOf(raw string) (string, error) {
+	if len(r.KeyColumns) <= 1 {
+		return raw, nil
+	}
+	keys := r.joinedKeys[raw]
+	if len(keys) > 1 {
+		return "", fmt.Errorf("reference:%s is ambiguous, it matches %d keys of %s:%s",
+			raw, len(keys), r.Definition.ReferenceSheet, r.Definition.ReferenceKey)
+	}
+	return lo.FirstOrEmpty(keys), nil
+}
+
+// embedRow returns the referenced row of the key as a map of the embedded columns of sheet, which is either
+// the reference sheet or its copy for a target.
+func (r *Reference) embedRow(sheet *Sheet, key string) map[string]any {
+	row := sheet.Rows[r.rowIndexes[key]]
+	columns := r.Definition.EmbedColumns()
+	m := make(map[string]any)
+	for _, column := range sheet.Columns {
+		if !column.IsExportable() || (columns != nil && !lo.Contains(columns, column.Name)) {
+			continue
+		}
+		path, err := parseColumnPath(column.Name)
+		if err != nil {
+			m[column.Name] = row[column.Index].Value
+			continue
+		}
+		setColumnPath(m, path, row[column.Index].Value)
+	}
+	return m
+}
+
+// embedCell rebuilds the embedded rows of a cell resolved by the embed reference from sheet,
+// the copy of the reference sheet for a target.
+func (r *Reference) embedCell(sheet *Sheet, column *Column, cell *Cell) *Cell {
+	if cell.Value == nil {
+		return cell
+	}
+	if !column.Type.IsList() {
+		return &Cell{Column: cell.Column, Value: r.embedRow(sheet, cell.Raw), Raw: cell.Raw}
+	}
+	values := []any{}
+	for _, raw := range strings.Split(cell.Raw, column.Type.Separator()) {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		if key, err := r.keyOf(raw); err == nil {
+			values = append(values, r.embedRow(sheet, key))
+		}
+	}
+	return &Cell{Column: cell.Column, Value: values, Raw: cell.Raw}
 }
 
 func NewReferenceResolver(file *File, sheet *Sheet) (*ReferenceResolver, error) {
-	resolver := &ReferenceResolver{SheetReader: NewXLSXReader()}
+	resolver := &ReferenceResolver{SheetReader: file.sheetReader(), file: file}
 
 	for i, row := range sheet.Rows {
 		definition := &ReferenceDefinition{
@@ -68,14 +210,27 @@ func NewReferenceResolver(file *File, sheet *Sheet) (*ReferenceResolver, error)
 				definition.ReferenceValue = cell.Raw
 			case "reference_name":
 				definition.ReferenceName = cell.Raw
+			case "embed":
+				definition.Embed = cell.Raw
+			case "keep_key_as":
+				definition.KeepKeyAs = cell.Raw
 			default:
-				return nil, fmt.Errorf("unknown column: %s", cell.Column.Name)
+				return nil, sheet.CellError(0, nil, fmt.Errorf("unknown column: %s", cell.Column.Name))
 			}
 		}
 		if definition.PolymorphicReference() {
 			if definition.Sheet != definition.ReferenceSheet {
-				return nil, fmt.Errorf("PolymorphicReference Sheet(%s) and ReferenceSheet(%s) must match", definition.Sheet, definition.ReferenceSheet)
+				return nil, sheet.CellError(sheet.RowNumber(i), nil,
+					fmt.Errorf("PolymorphicReference Sheet(%s) and ReferenceSheet(%s) must match", definition.Sheet, definition.ReferenceSheet))
 			}
+			if definition.CompositeReference() {
+				return nil, sheet.CellError(sheet.RowNumber(i), nil,
+					fmt.Errorf("PolymorphicReference reference_key(%s) must be a single column", definition.ReferenceKey))
+			}
+		}
+		if n := len(definition.Columns()); n > 1 && n != len(definition.ReferenceKeys()) {
+			return nil, sheet.CellError(sheet.RowNumber(i), nil,
+				fmt.Errorf("column(%s) and reference_key(%s) must have the same number of columns", definition.Column, definition.ReferenceKey))
 		}
 		resolver.ReferenceDefinitions = append(resolver.ReferenceDefinitions, definition)
 	}
@@ -86,6 +241,8 @@ type ReferenceResolver struct {
 	SheetReader          SheetReader
 	ReferenceDefinitions []*ReferenceDefinition
 
+	// file serves the references to its own sheets. SheetReader is used for every reference if nil.
+	file       *File
 	references []*Reference
 }
 
@@ -93,10 +250,11 @@ func (r *ReferenceResolver) References() ([]*Reference, error) {
 	if r.references != nil {
 		return r.references, nil
 	}
-	r.references = make([]*Reference, len(r.ReferenceDefinitions))
+	references := make([]*Reference, len(r.ReferenceDefinitions))
 
+	var failures []error
 	for i, referenceDefinition := range r.ReferenceDefinitions {
-		referenceSheet, err := r.SheetReader.Open(referenceDefinition.ReferenceFilePath(), referenceDefinition.ReferenceSheet)
+		referenceSheet, err := r.openReferenceSheet(referenceDefinition)
 		if err != nil {
 			return nil, errs.Wrap(err, "open reference sheet")
 		}
@@ -108,43 +266,120 @@ func (r *ReferenceResolver) References() ([]*Reference, error) {
 			}
 			reference := &Reference{
 				Definition: referenceDefinition,
+				Sheet:      referenceSheet,
 				KeyColumn:  referenceSheet.Columns[k.Index],
 			}
-			r.references[i] = reference
+			references[i] = reference
 		} else {
-			k, err := referenceSheet.Column(referenceDefinition.ReferenceKey)
-			if err != nil {
-				return nil, errs.Wrap(err, "find reference key column")
+			var keyColumns []*Column
+			for _, name := range referenceDefinition.ReferenceKeys() {
+				k, err := referenceSheet.Column(name)
+				if err != nil {
+					return nil, errs.Wrap(err, "find reference key column")
+				}
+				keyColumns = append(keyColumns, k)
 			}
-			v, err := referenceSheet.Column(referenceDefinition.ReferenceValue)
-			if err != nil {
-				return nil, errs.Wrap(err, "find reference value column")
+			if len(keyColumns) == 0 {
+				return nil, fmt.Errorf("sheet:%s reference_key is empty", referenceDefinition.ReferenceSheet)
+			}
+			k := keyColumns[0]
+			v := k
+			if !referenceDefinition.EmbedReference() {
+				if v, err = referenceSheet.Column(referenceDefinition.ReferenceValue); err != nil {
+					return nil, errs.Wrap(err, "find reference value column")
+				}
+			}
+			for _, name := range referenceDefinition.EmbedColumns() {
+				if _, err := referenceSheet.Column(name); err != nil {
+					return nil, errs.Wrap(err, "find embed column")
+				}
 			}
+			// The keys and values are read as loaded, whether the reference sheet is resolved before or after,
+			// and the value column is copied to keep its type in line with them.
+			rows, valueColumn := referenceSheet.unresolved(v.Index)
 			reference := &Reference{
 				Definition:  referenceDefinition,
-				KeyColumn:   referenceSheet.Columns[k.Index],
-				ValueColumn: referenceSheet.Columns[v.Index],
-				Keys:        make([]*Cell, len(referenceSheet.Rows)),
-				Values:      make([]*Cell, len(referenceSheet.Rows)),
+				Sheet:       referenceSheet,
+				KeyColumn:   k,
+				KeyColumns:  keyColumns,
+				ValueColumn: &valueColumn,
+				Keys:        make([]*Cell, len(rows)),
+				Values:      make([]*Cell, len(rows)),
 				ValueMap:    make(map[string]*Cell),
+				rowIndexes:  make(map[string]int),
+				embedded:    make(map[string]map[string]any),
+				joinedKeys:  make(map[string][]string),
 			}
-			for j, row := range referenceSheet.Rows {
+			rowNumbers := make(map[string]int)
+			for j, row := range rows {
+				values := keyValues(row, keyColumns)
 				reference.Keys[j] = row[k.Index]
+				if len(keyColumns) > 1 {
+					joined := joinKey(values)
+					reference.Keys[j] = &Cell{Column: k, Value: joined, Raw: joined}
+				}
 				reference.Values[j] = row[v.Index]
-				reference.ValueMap[reference.Keys[j].Raw] = reference.Values[j]
+
+				key := encodeKey(values)
+				if first, ok := rowNumbers[key]; ok && reference.Keys[j].Raw != "" {
+					err := referenceSheet.CellError(referenceSheet.RowNumber(j), k,
+						fmt.Errorf("reference key:%s is duplicated with row:%d", reference.Keys[j].Raw, first))
+					if !referenceDefinition.SameWorkbook() {
+						err = errs.WithFile(err, referenceDefinition.ReferenceFileName())
+					}
+					failures = append(failures, err)
+					continue
+				}
+				rowNumbers[key] = referenceSheet.RowNumber(j)
+				reference.ValueMap[key] = reference.Values[j]
+				reference.rowIndexes[key] = j
+				if len(keyColumns) > 1 {
+					reference.joinedKeys[reference.Keys[j].Raw] = append(reference.joinedKeys[reference.Keys[j].Raw], key)
+				}
 			}
-			r.references[i] = reference
+			references[i] = reference
 		}
 	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	r.references = references
 	return r.references, nil
 }
 
-func (r *ReferenceResolver) Resolve(sheet *Sheet) error {
+// Resolve replaces the reference keys of the sheet with the referenced values. A sheet is resolved only once,
+// and later calls return the result of the first one. A sheet that is reached again while it is being resolved,
+// through embed references, is reported as a cycle.
+func (r *ReferenceResolver) Resolve(sheet *Sheet) (err error) {
+	if sheet.resolved {
+		return sheet.resolveErr
+	}
+	if sheet.resolving {
+		return sheet.CellError(0, nil, errors.New("reference cycle detected"))
+	}
+	sheet.resolving = true
+	sheet.snapshot()
+	defer func() {
+		sheet.resolving = false
+		sheet.resolved = true
+		sheet.resolveErr = err
+	}()
+
 	references, err := r.References()
 	if err != nil {
 		return errs.Wrap(err, "load references for resolve")
 	}
 
+	// Keep the keys before any of them is rewritten by the resolution below.
+	var failures []error
+	for _, reference := range references {
+		if reference.Definition.Sheet == sheet.Name && reference.Definition.KeepKeyAs != "" {
+			if err := keepKey(sheet, reference.Definition); err != nil {
+				failures = append(failures, err)
+			}
+		}
+	}
+
 	// Resolve the poymorphic reference first, since poymorphic reference resolution cannot be performed
 	// if the value of reference_key has already been rewritten.
 	names := make(map[string]*Reference)
@@ -168,19 +403,35 @@ func (r *ReferenceResolver) Resolve(sheet *Sheet) error {
 			for i, row := range sheet.Rows {
 				r, ok := names[row[reference.KeyColumn.Index].Raw]
 				if !ok {
-					return fmt.Errorf("sheet:%s row:%d column:%s reference_name:%s not found",
-						sheet.Name, i+1, column.Name, row[reference.KeyColumn.Index].Raw)
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), reference.KeyColumn,
+						fmt.Errorf("column:%s reference_name:%s not found", column.Name, row[reference.KeyColumn.Index].Raw)))
+					continue
 				}
-				if v, ok := r.ValueMap[row[column.Index].Raw]; ok {
+				if r.Definition.EmbedReference() {
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), column,
+						fmt.Errorf("column:%s reference_name:%s is an embed reference", column.Name, r.Definition.ReferenceName)))
+					continue
+				}
+				key, err := r.keyOf(row[column.Index].Raw)
+				if err != nil {
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), column, fmt.Errorf("column:%s %w", column.Name, err)))
+					continue
+				}
+				if v, ok := r.Lookup(key); ok {
 					row[column.Index] = v
 				} else {
-					return fmt.Errorf("sheet:%s row:%d column:%s reference:%s value not found from %s:%s",
-						sheet.Name, i+1, column.Name, row[column.Index].Raw, reference.Definition.ReferenceSheet, reference.Definition.ReferenceKey)
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), column,
+						fmt.Errorf("column:%s reference:%s value not found from %s:%s",
+							column.Name, row[column.Index].Raw, reference.Definition.ReferenceSheet, reference.Definition.ReferenceKey)))
+					continue
 				}
-				if column.Type == "" || column.Type == "ref" {
+				if column.Type == "" || column.Type == ColumnTypeRef {
 					column.Type = r.ValueColumn.Type
-				} else if column.Type != r.ValueColumn.Type {
-					return fmt.Errorf("sheet:%s row:%d column:%s value type mismatch: %s, %s", sheet.Name, i+1, column.Name, column.Type, r.ValueColumn.Type)
+				} else if column.Type == ColumnTypeRef.Nullable() {
+					column.Type = r.ValueColumn.Type.Nullable()
+				} else if column.Type.NonNull() != r.ValueColumn.Type.NonNull() {
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), column,
+						fmt.Errorf("column:%s value type mismatch: %s, %s", column.Name, column.Type, r.ValueColumn.Type)))
 				}
 			}
 		}
@@ -188,56 +439,259 @@ func (r *ReferenceResolver) Resolve(sheet *Sheet) error {
 
 	// Resolve a regular reference after resolving a poymorphic reference
 	for _, reference := range references {
-		if reference.Definition.Sheet != sheet.Name {
+		if reference.Definition.Sheet != sheet.Name || reference.Definition.PolymorphicReference() {
 			continue
 		}
-		for _, column := range sheet.Columns {
-			if reference.Definition.Column != column.Name {
-				continue
+		names := reference.Definition.Columns()
+		if len(names) == 0 {
+			continue
+		}
+		column, err := sheet.Column(names[0])
+		if err != nil {
+			continue
+		}
+		keyColumns := make([]*Column, len(names))
+		for j, name := range names {
+			if keyColumns[j], err = sheet.Column(name); err != nil {
+				break
 			}
-			if reference.Definition.PolymorphicReference() {
+		}
+		if err != nil {
+			failures = append(failures, sheet.CellError(0, nil, err))
+			continue
+		}
+		if reference.Definition.EmbedReference() {
+			// The referenced rows are embedded as they are exported, so their own references are resolved first.
+			if err := r.resolveReferenceSheet(reference); err != nil {
+				failures = append(failures, err)
 				continue
 			}
+			column.embed = reference
+		}
+		if column.Type.IsList() {
+			failures = append(failures, r.resolveList(sheet, column, reference)...)
+			continue
+		}
 
-			for i, row := range sheet.Rows {
-				if row[column.Index].Raw == "" {
+		for i, row := range sheet.Rows {
+			values := keyValues(row, keyColumns)
+			raw := joinKey(values)
+			if strings.Trim(raw, CompositeKeySeparator) == "" {
+				if reference.Definition.EmbedReference() {
+					row[column.Index] = &Cell{Column: column, Value: nil, Raw: row[column.Index].Raw}
+				}
+				continue
+			}
+			key := encodeKey(values)
+			if len(keyColumns) == 1 {
+				if key, err = reference.keyOf(raw); err != nil {
+					failures = append(failures, sheet.CellError(sheet.RowNumber(i), column, fmt.Errorf("column:%s %w", column.Name, err)))
 					continue
 				}
-				if v, ok := reference.ValueMap[row[column.Index].Raw]; ok {
-					row[column.Index] = v
-				} else {
-					return fmt.Errorf("sheet: %s, row: %d, column: %s, reference: %s, value not found from %s:%s",
-						sheet.Name, i+1, column.Name, row[column.Index].Raw, reference.Definition.ReferenceSheet, reference.Definition.ReferenceKey)
+			}
+			if v, ok := reference.Lookup(key); ok {
+				row[column.Index] = v
+			} else {
+				failures = append(failures, sheet.CellError(sheet.RowNumber(i), column,
+					fmt.Errorf("column:%s reference:%s value not found from %s:%s",
+						reference.Definition.Column, raw, reference.Definition.ReferenceSheet, reference.Definition.ReferenceKey)))
+			}
+		}
+		if column.Type.IsNullable() && !reference.Definition.EmbedReference() {
+			column.Type = reference.ValueType().Nullable()
+		} else {
+			column.Type = reference.ValueType()
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// keepKey appends a column named by keep_key_as that holds the keys of the reference as strings.
+// The keys of a list of ref column are kept as a list.
+func keepKey(sheet *Sheet, definition *ReferenceDefinition) error {
+	if _, err := sheet.Column(definition.KeepKeyAs); err == nil {
+		return sheet.CellError(0, nil, fmt.Errorf("keep_key_as:%s conflicts with an existing column", definition.KeepKeyAs))
+	}
+	var keyColumns []*Column
+	for _, name := range definition.Columns() {
+		column, err := sheet.Column(name)
+		if err != nil {
+			return sheet.CellError(0, nil, err)
+		}
+		keyColumns = append(keyColumns, column)
+	}
+	if len(keyColumns) == 0 {
+		return nil
+	}
+
+	column := &Column{
+		Name:        definition.KeepKeyAs,
+		Type:        ColumnTypeString,
+		Index:       len(sheet.Columns),
+		Description: keyColumns[0].Description,
+		Targets:     keyColumns[0].Targets,
+	}
+	if t := keyColumns[0].Type; t.IsList() {
+		column.Type = ListOf(ColumnTypeString, t.Separator())
+	}
+	sheet.Columns = append(sheet.Columns, column)
+
+	for i, row := range sheet.Rows {
+		key := joinKey(keyValues(row, keyColumns))
+		var value any = key
+		if column.Type.IsList() {
+			values := []any{}
+			for _, s := range strings.Split(key, column.Type.Separator()) {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
 				}
 			}
-			column.Type = reference.ValueColumn.Type
+			value = values
 		}
+		sheet.Rows[i] = append(row, &Cell{Column: column, Value: value, Raw: key})
 	}
 	return nil
 }
 
+// openReferenceSheet opens the referenced sheet, taking the sheets of the same workbook from the open file.
+func (r *ReferenceResolver) openReferenceSheet(definition *ReferenceDefinition) (*Sheet, error) {
+	if r.file != nil && definition.SameWorkbook() {
+		return r.file.DataSheet(definition.ReferenceSheet)
+	}
+	return r.SheetReader.Open(definition.ReferenceFilePath(), definition.ReferenceSheet)
+}
+
+// resolveReferenceSheet resolves the references of the reference sheet when the reader supports it.
+func (r *ReferenceResolver) resolveReferenceSheet(reference *Reference) error {
+	if r.file != nil && reference.Definition.SameWorkbook() {
+		return r.Resolve(reference.Sheet)
+	}
+	resolver, ok := r.SheetReader.(SheetResolver)
+	if !ok {
+		return nil
+	}
+	return resolver.Resolve(reference.Definition.ReferenceFilePath(), reference.Sheet)
+}
+
+// resolveList resolves a list of ref column such as []ref, whose cells hold separated keys.
+// Every missing key is reported separately.
+func (r *ReferenceResolver) resolveList(sheet *Sheet, column *Column, reference *Reference) []error {
+	if len(reference.Definition.Columns()) > 1 {
+		return []error{sheet.CellError(0, column, fmt.Errorf("column:%s list of ref cannot have a composite key over several columns", column.Name))}
+	}
+	if reference.ValueType().IsList() {
+		return []error{sheet.CellError(0, column, fmt.Errorf("column:%s list of ref cannot reference list value:%s", column.Name, reference.ValueType()))}
+	}
+
+	var failures []error
+	for i, row := range sheet.Rows {
+		raw := row[column.Index].Raw
+		values := []any{}
+		for _, element := range strings.Split(raw, column.Type.Separator()) {
+			element = strings.TrimSpace(element)
+			if element == "" {
+				continue
+			}
+			key, err := reference.keyOf(element)
+			if err != nil {
+				failures = append(failures, sheet.CellError(sheet.RowNumber(i), column, fmt.Errorf("column:%s %w", column.Name, err)))
+				continue
+			}
+			if v, ok := reference.Lookup(key); ok {
+				values = append(values, v.Value)
+			} else {
+				failures = append(failures, sheet.CellError(sheet.RowNumber(i), column,
+					fmt.Errorf("column:%s reference:%s value not found from %s:%s",
+						column.Name, element, reference.Definition.ReferenceSheet, reference.Definition.ReferenceKey)))
+			}
+		}
+		row[column.Index] = &Cell{Column: column, Value: values, Raw: raw}
+	}
+	column.Type = ListOf(reference.ValueType().NonNull(), column.Type.Separator())
+	return failures
+}
+
 type SheetReader interface {
 	Open(file string, sheet string) (*Sheet, error)
 }
 
-func NewXLSXReader() SheetReader {
-	return &XLSXReader{file: make(map[string]*File)}
+// SheetResolver is implemented by readers that can resolve the references of the sheets they opened,
+// using the reference definitions of their workbooks.
+type SheetResolver interface {
+	Resolve(file string, sheet *Sheet) error
+}
+
+func NewXLSXReader(option OpenOption) SheetReader {
+	return &XLSXReader{option: option, file: make(map[string]*File)}
 }
 
+// XLSXReader reads the sheets of workbooks. Reference files of other formats, such as csv or json, are passed
+// to the reader chosen by NewFileReader.
 type XLSXReader struct {
-	file map[string]*File
+	option  OpenOption
+	file    map[string]*File
+	readers map[string]SheetReader
 }
 
 func (r *XLSXReader) Open(path string, sheet string) (*Sheet, error) {
+	if reader, ok := r.fileReader(path); ok {
+		return reader.Open(path, sheet)
+	}
+	file, err := r.open(path)
+	if err != nil {
+		return nil, err
+	}
+	return file.DataSheet(sheet)
+}
+
+// Resolve resolves the sheet with the reference definitions of the workbook at path.
+func (r *XLSXReader) Resolve(path string, sheet *Sheet) error {
+	if _, ok := r.fileReader(path); ok {
+		// Files other than workbooks have no reference definitions.
+		return nil
+	}
+	file, err := r.open(path)
+	if err != nil {
+		return err
+	}
+	resolver, err := file.ReferenceResolver()
+	if err != nil {
+		return errs.Wrap(errs.WithFile(err, filepath.Base(path)), "load reference resolver")
+	}
+	return errs.WithFile(resolver.Resolve(sheet), filepath.Base(path))
+}
+
+// open opens the workbook once. Workbooks opened by the reader share it, so that every sheet is loaded
+// and resolved only once and reference cycles across workbooks can be detected.
+func (r *XLSXReader) open(path string) (*File, error) {
+	path = filepath.Clean(path)
 	if f, ok := r.file[path]; ok {
-		return f.DataSheet(sheet)
+		return f, nil
 	}
-	file, err := Open(path)
+	file, err := Open(path, r.option)
 	if err != nil {
 		return nil, errs.Wrap(err, "open reference file")
 	}
+	file.reader = r
 	r.file[path] = file
-	return file.DataSheet(sheet)
+	return file, nil
+}
+
+// fileReader returns the reader shared by the reference files with the extension of path.
+func (r *XLSXReader) fileReader(path string) (SheetReader, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if reader, ok := r.readers[ext]; ok {
+		return reader, true
+	}
+	reader, ok := NewFileReader(path, r.option)
+	if !ok {
+		return nil, false
+	}
+	if r.readers == nil {
+		r.readers = make(map[string]SheetReader)
+	}
+	r.readers[ext] = reader
+	return reader, true
 }
 
 type MemoryReader struct {
@@ -248,5 +702,5 @@ func (r *MemoryReader) Open(path string, sheet string) (*Sheet, error) {
 	if s, ok := r.Sheet[sheet]; ok {
 		return s, nil
 	}
-	return NewDataSeet(sheet, nil)
+	return NewDataSeet(sheet, nil, SheetOption{})
 }
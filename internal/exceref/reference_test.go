@@ -3,8 +3,10 @@ package exceref_test
 import (
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 
+	"github.com/daichirata/exceref/internal/errs"
 	"github.com/daichirata/exceref/internal/exceref"
 )
 
@@ -16,7 +18,348 @@ func TestReferenceDefinition_ReferenceFileName(t *testing.T) {
 	require.Equal(t, "Book1.xlsx", def2.ReferenceFileName())
 }
 
+func TestReferenceDefinition_SameWorkbook(t *testing.T) {
+	require.True(t, (&exceref.ReferenceDefinition{}).SameWorkbook())
+	require.True(t, (&exceref.ReferenceDefinition{ReferenceFile: "."}).SameWorkbook())
+	require.False(t, (&exceref.ReferenceDefinition{ReferenceFile: "Book1"}).SameWorkbook())
+}
+
 func TestReferenceDefinition_ReferenceFilePath(t *testing.T) {
 	def := exceref.ReferenceDefinition{ReferenceFile: "Book1.xlsx", BaseDir: "/tmp/exceref"}
 	require.Equal(t, "/tmp/exceref/Book1.xlsx", def.ReferenceFilePath())
 }
+
+func TestReferenceResolver_ReferencesDuplicateKey(t *testing.T) {
+	master, err := exceref.NewDataSeet("master", [][]string{
+		{"string", "int"},
+		{"code", "id"},
+		{},
+		{"sword", "1"},
+		{"shield", "2"},
+		{"sword", "3"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"master": master}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "items", Column: "item", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id"},
+		},
+	}
+	_, err = resolver.References()
+	require.ErrorContains(t, err, "master!A6: reference key:sword is duplicated with row:4")
+}
+
+func TestReferenceResolver_ResolveErrors(t *testing.T) {
+	master, err := exceref.NewDataSeet("master", [][]string{
+		{"string", "int"},
+		{"code", "id"},
+		{},
+		{"sword", "1"},
+		{"shield", "2"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	items, err := exceref.NewDataSeet("items", [][]string{
+		{"string", "ref"},
+		{"name", "item"},
+		{},
+		{"a", "bow"},
+		{"b", "sword"},
+		{"c", "axe"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"master": master}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "items", Column: "item", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id"},
+		},
+	}
+	err = resolver.Resolve(items)
+	require.Error(t, err)
+
+	var messages []string
+	for _, e := range errs.Flatten(err) {
+		messages = append(messages, e.Error())
+	}
+	require.Equal(t, []string{
+		"items!B4: column:item reference:bow value not found from master:code",
+		"items!B6: column:item reference:axe value not found from master:code",
+	}, messages)
+}
+
+func TestReferenceResolver_ResolveCompositeKey(t *testing.T) {
+	waves, err := exceref.NewDataSeet("waves", [][]string{
+		{"int", "int", "string"},
+		{"stage_id", "wave", "code"},
+		{},
+		{"1", "1", "w_1_1"},
+		{"1", "2", "w_1_2"},
+		{"2", "1", "w_2_1"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	enemies, err := exceref.NewDataSeet("enemies", [][]string{
+		{"string", "ref", "int", "ref"},
+		{"name", "stage_id", "wave", "wave_key"},
+		{},
+		{"slime", "1", "2", "2|1"},
+		{"golem", "2", "1", ""},
+		{"dragon", "2", "2", "3|1"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"waves": waves}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "enemies", Column: "stage_id, wave", ReferenceSheet: "waves", ReferenceKey: "stage_id, wave", ReferenceValue: "code"},
+			{Sheet: "enemies", Column: "wave_key", ReferenceSheet: "waves", ReferenceKey: "stage_id,wave", ReferenceValue: "code"},
+		},
+	}
+	references, err := resolver.References()
+	require.NoError(t, err)
+	require.Equal(t, []string{"1|1", "1|2", "2|1"}, lo.Map(references[0].Keys, func(c *exceref.Cell, _ int) string {
+		return c.Raw
+	}))
+
+	err = resolver.Resolve(enemies)
+	require.Error(t, err)
+	var messages []string
+	for _, e := range errs.Flatten(err) {
+		messages = append(messages, e.Error())
+	}
+	require.Equal(t, []string{
+		"enemies!B6: column:stage_id, wave reference:2|2 value not found from waves:stage_id, wave",
+		"enemies!D6: column:wave_key reference:3|1 value not found from waves:stage_id,wave",
+	}, messages)
+	require.Equal(t, []map[string]any{
+		{"name": "slime", "stage_id": "w_1_2", "wave": 2, "wave_key": "w_2_1"},
+		{"name": "golem", "stage_id": "w_2_1", "wave": 1, "wave_key": ""},
+		{"name": "dragon", "stage_id": "2", "wave": 2, "wave_key": "3|1"},
+	}, enemies.Map())
+}
+
+func TestReferenceResolver_ResolveCompositeKeySeparator(t *testing.T) {
+	codes, err := exceref.NewDataSeet("codes", [][]string{
+		{"string", "string", "int"},
+		{"group", "code", "id"},
+		{},
+		{"x|y", "z", "1"},
+		{"x", "y|z", "2"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	items, err := exceref.NewDataSeet("items", [][]string{
+		{"ref", "string", "ref"},
+		{"group", "code", "joined"},
+		{},
+		{"x", "y|z", ""},
+		{"x|y", "z", "x|y|z"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"codes": codes}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "items", Column: "group,code", ReferenceSheet: "codes", ReferenceKey: "group,code", ReferenceValue: "id"},
+			{Sheet: "items", Column: "joined", ReferenceSheet: "codes", ReferenceKey: "group,code", ReferenceValue: "id"},
+		},
+	}
+	err = resolver.Resolve(items)
+	require.Error(t, err)
+	require.Equal(t, []string{
+		"items!C5: column:joined reference:x|y|z is ambiguous, it matches 2 keys of codes:group,code",
+	}, lo.Map(errs.Flatten(err), func(e error, _ int) string {
+		return e.Error()
+	}))
+	require.Equal(t, []any{2, 1}, lo.Map(items.Rows, func(row exceref.Row, _ int) any {
+		return row[0].Value
+	}))
+}
+
+func TestReferenceResolver_ResolvePolymorphicCompositeKey(t *testing.T) {
+	waves, err := exceref.NewDataSeet("waves", [][]string{
+		{"int", "int", "string"},
+		{"stage_id", "wave", "code"},
+		{},
+		{"1", "1", "w_1_1"},
+		{"1", "2", "w_1_2"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	items, err := exceref.NewDataSeet("items", [][]string{
+		{"string", "string"},
+		{"code", "name"},
+		{},
+		{"sword", "Sword"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	rewards, err := exceref.NewDataSeet("rewards", [][]string{
+		{"string", "ref"},
+		{"kind", "target"},
+		{},
+		{"wave", "1|2"},
+		{"item", "sword"},
+		{"wave", "2|1"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"waves": waves, "items": items, "rewards": rewards}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "rewards", Column: "target", ReferenceSheet: "rewards", ReferenceKey: "kind"},
+			{ReferenceSheet: "waves", ReferenceKey: "stage_id,wave", ReferenceValue: "code", ReferenceName: "wave"},
+			{ReferenceSheet: "items", ReferenceKey: "code", ReferenceValue: "name", ReferenceName: "item"},
+		},
+	}
+	err = resolver.Resolve(rewards)
+	require.Equal(t, []string{
+		"rewards!B6: column:target reference:2|1 value not found from rewards:kind",
+	}, lo.Map(errs.Flatten(err), func(e error, _ int) string {
+		return e.Error()
+	}))
+	require.Equal(t, []any{"w_1_2", "Sword", "2|1"}, lo.Map(rewards.Rows, func(row exceref.Row, _ int) any {
+		return row[1].Value
+	}))
+}
+
+func TestNewReferenceResolver_CompositeKeyMismatch(t *testing.T) {
+	_, err := exceref.NewReferenceResolver(&exceref.File{}, exceref.NewReferenceDefinitionSheet("_references", [][]string{
+		{"sheet", "column", "reference_sheet", "reference_key", "reference_value"},
+		{"enemies", "stage_id,wave", "waves", "stage_id,wave,kind", "code"},
+	}))
+	require.Error(t, err)
+}
+
+func TestReferenceResolver_ResolveList(t *testing.T) {
+	master, err := exceref.NewDataSeet("master", [][]string{
+		{"string", "int"},
+		{"code", "id"},
+		{},
+		{"sword", "1"},
+		{"shield", "2"},
+		{"potion", "3"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	sets, err := exceref.NewDataSeet("sets", [][]string{
+		{"string", "[]ref", "[]ref(;)"},
+		{"name", "items", "gifts"},
+		{},
+		{"starter", "sword, shield,potion", "potion"},
+		{"empty", "", ""},
+		{"broken", "sword,bow,axe", "shield;staff"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"master": master}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "sets", Column: "items", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id"},
+			{Sheet: "sets", Column: "gifts", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id"},
+		},
+	}
+	err = resolver.Resolve(sets)
+	require.Error(t, err)
+	var messages []string
+	for _, e := range errs.Flatten(err) {
+		messages = append(messages, e.Error())
+	}
+	require.Equal(t, []string{
+		"sets!B6: column:items reference:bow value not found from master:code",
+		"sets!B6: column:items reference:axe value not found from master:code",
+		"sets!C6: column:gifts reference:staff value not found from master:code",
+	}, messages)
+
+	require.Equal(t, exceref.ColumnType("[]int"), sets.Columns[1].Type)
+	require.Equal(t, exceref.ColumnType("[]int(;)"), sets.Columns[2].Type)
+	require.Equal(t, []map[string]any{
+		{"name": "starter", "items": []any{1, 2, 3}, "gifts": []any{3}},
+		{"name": "empty", "items": []any{}, "gifts": []any{}},
+		{"name": "broken", "items": []any{1}, "gifts": []any{2}},
+	}, sets.Map())
+}
+
+func TestReferenceResolver_ResolveEmbed(t *testing.T) {
+	weapons, err := exceref.NewDataSeet("weapons", [][]string{
+		{"string", "int", "int", "string"},
+		{"code", "attack", "rarity", "memo"},
+		{},
+		{"sword", "10", "1", "basic"},
+		{"axe", "15", "2", ""},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	units, err := exceref.NewDataSeet("units", [][]string{
+		{"string", "ref", "[]ref"},
+		{"name", "weapon", "spares"},
+		{},
+		{"knight", "sword", "axe,sword"},
+		{"mage", "", ""},
+		{"orc", "club", "axe"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"weapons": weapons}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "units", Column: "weapon", ReferenceSheet: "weapons", ReferenceKey: "code", Embed: "*"},
+			{Sheet: "units", Column: "spares", ReferenceSheet: "weapons", ReferenceKey: "code", Embed: "code, attack"},
+		},
+	}
+	require.False(t, resolver.ReferenceDefinitions[0].PolymorphicReference())
+	err = resolver.Resolve(units)
+	require.Error(t, err)
+	require.Len(t, errs.Flatten(err), 1)
+	require.EqualError(t, errs.Flatten(err)[0], "units!B6: column:weapon reference:club value not found from weapons:code")
+
+	sword := map[string]any{"code": "sword", "attack": 10, "rarity": 1, "memo": "basic"}
+	require.Equal(t, []map[string]any{
+		{"name": "knight", "weapon": sword, "spares": []any{
+			map[string]any{"code": "axe", "attack": 15},
+			map[string]any{"code": "sword", "attack": 10},
+		}},
+		{"name": "mage", "weapon": nil, "spares": []any{}},
+		{"name": "orc", "weapon": "club", "spares": []any{map[string]any{"code": "axe", "attack": 15}}},
+	}, units.Map())
+	require.Equal(t, exceref.ObjectOf("weapons"), units.Columns[1].Type)
+	require.Equal(t, exceref.ColumnType("[]object:weapons"), units.Columns[2].Type)
+
+	require.Equal(t, err, resolver.Resolve(units), "a resolved sheet returns the result of its resolution")
+}
+
+func TestReferenceResolver_ResolveKeepKey(t *testing.T) {
+	master, err := exceref.NewDataSeet("master", [][]string{
+		{"string", "int"},
+		{"code", "id"},
+		{},
+		{"item_sword", "1"},
+		{"item_shield", "2"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	sets, err := exceref.NewDataSeet("sets", [][]string{
+		{"string", "ref", "[]ref(;)"},
+		{"name", "item_id", "extra_ids"},
+		{},
+		{"starter", "item_sword", "item_shield;item_sword"},
+		{"empty", "", ""},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+
+	resolver := &exceref.ReferenceResolver{
+		SheetReader: &exceref.MemoryReader{Sheet: map[string]*exceref.Sheet{"master": master}},
+		ReferenceDefinitions: []*exceref.ReferenceDefinition{
+			{Sheet: "sets", Column: "item_id", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id", KeepKeyAs: "item"},
+			{Sheet: "sets", Column: "extra_ids", ReferenceSheet: "master", ReferenceKey: "code", ReferenceValue: "id", KeepKeyAs: "extras"},
+		},
+	}
+	require.NoError(t, resolver.Resolve(sets))
+	require.Equal(t, []map[string]any{
+		{"name": "starter", "item_id": 1, "item": "item_sword", "extra_ids": []any{2, 1}, "extras": []any{"item_shield", "item_sword"}},
+		{"name": "empty", "item_id": "", "item": "", "extra_ids": []any{}, "extras": []any{}},
+	}, sets.Map())
+	require.Equal(t, exceref.ColumnType("[]string(;)"), sets.Columns[4].Type)
+
+	sets, err = exceref.NewDataSeet("sets", [][]string{{"string", "ref"}, {"name", "item_id"}}, exceref.SheetOption{})
+	require.NoError(t, err)
+	resolver.ReferenceDefinitions[0].KeepKeyAs = "name"
+	require.Error(t, (&exceref.ReferenceResolver{
+		SheetReader:          resolver.SheetReader,
+		ReferenceDefinitions: resolver.ReferenceDefinitions[:1],
+	}).Resolve(sets))
+}
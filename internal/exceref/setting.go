@@ -0,0 +1,199 @@
+package exceref
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daichirata/exceref/internal/errs"
+	"github.com/samber/lo"
+)
+
+const SettingSheetName = "_settings"
+
+const (
+	SettingKeyTypeRow        = "type_row"
+	SettingKeyNameRow        = "name_row"
+	SettingKeyDescriptionRow = "description_row"
+	SettingKeyBodyRow        = "body_row"
+	SettingKeyTargetRow      = "target_row"
+	SettingKeyCommentMarker  = "comment_marker"
+	SettingKeyTimeLayouts    = "time_layouts"
+	SettingKeyTimezone       = "timezone"
+	// SettingKeyValidationHeadroom is the number of empty rows past the data that the drop-down lists cover.
+	SettingKeyValidationHeadroom = "validation_headroom"
+)
+
+// DefaultValidationHeadroom is the validation_headroom used if not set.
+const DefaultValidationHeadroom = 1000
+
+// TimeLayoutSeparator separates the layouts of the time_layouts setting.
+const TimeLayoutSeparator = "|"
+
+var settingKeys = []string{
+	SettingKeyTypeRow,
+	SettingKeyNameRow,
+	SettingKeyDescriptionRow,
+	SettingKeyBodyRow,
+	SettingKeyTargetRow,
+	SettingKeyCommentMarker,
+	SettingKeyTimeLayouts,
+	SettingKeyTimezone,
+	SettingKeyValidationHeadroom,
+}
+
+// Setting is a row of the _settings sheet. An empty Sheet applies the setting to the whole workbook.
+type Setting struct {
+	Sheet string
+	Key   string
+	Value string
+}
+
+type Settings []*Setting
+
+func NewSettings(sheet *Sheet) (Settings, error) {
+	var settings Settings
+
+	for i, row := range sheet.Rows {
+		setting := &Setting{}
+		for _, cell := range row {
+			switch cell.Column.Name {
+			case "sheet":
+				setting.Sheet = cell.Raw
+			case "key":
+				setting.Key = cell.Raw
+			case "value":
+				setting.Value = cell.Raw
+			default:
+				return nil, fmt.Errorf("unknown column: %s", cell.Column.Name)
+			}
+		}
+		if setting.Key == "" {
+			continue
+		}
+		if !lo.Contains(settingKeys, setting.Key) {
+			return nil, fmt.Errorf("row:%d unknown setting key: %s", i+1, setting.Key)
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// NewDefaultSettings builds workbook settings from key and value pairs, e.g. the settings of a project config.
+func NewDefaultSettings(values map[string]string) (Settings, error) {
+	keys := lo.Keys(values)
+	sort.Strings(keys)
+
+	var settings Settings
+	for _, key := range keys {
+		if !lo.Contains(settingKeys, key) {
+			return nil, fmt.Errorf("unknown setting key: %s", key)
+		}
+		settings = append(settings, &Setting{Key: key, Value: values[key]})
+	}
+	return settings, nil
+}
+
+// Get returns the value of the key for the sheet, falling back to the workbook setting.
+func (s Settings) Get(sheet, key string) string {
+	var value string
+	for _, setting := range s {
+		if setting.Key != key {
+			continue
+		}
+		if setting.Sheet == sheet {
+			return setting.Value
+		}
+		if setting.Sheet == "" {
+			value = setting.Value
+		}
+	}
+	return value
+}
+
+// SheetOption returns the parse options of the sheet given by the settings: the layout, the comment marker,
+// the time layouts and the timezone, which falls back to location.
+func (s Settings) SheetOption(sheet string, location *time.Location) (SheetOption, error) {
+	var option SheetOption
+	var err error
+	if option.Layout, err = s.Layout(sheet); err != nil {
+		return option, errs.Wrap(err, "load layout")
+	}
+	option.CommentMarker = s.Get(sheet, SettingKeyCommentMarker)
+	option.TimeLayouts = s.TimeLayouts(sheet)
+	if option.Location, err = s.Location(sheet); err != nil {
+		return option, errs.Wrap(err, "load timezone")
+	}
+	if option.Location == nil {
+		option.Location = location
+	}
+	return option, nil
+}
+
+// TimeLayouts returns the extra time layouts for the sheet.
+func (s Settings) TimeLayouts(sheet string) []string {
+	value := s.Get(sheet, SettingKeyTimeLayouts)
+	if value == "" {
+		return nil
+	}
+	return lo.Map(strings.Split(value, TimeLayoutSeparator), func(s string, _ int) string {
+		return strings.TrimSpace(s)
+	})
+}
+
+// Location returns the timezone of the sheet, or nil if not set.
+func (s Settings) Location(sheet string) (*time.Location, error) {
+	value := s.Get(sheet, SettingKeyTimezone)
+	if value == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("sheet:%s invalid %s: %s", sheet, SettingKeyTimezone, value)
+	}
+	return loc, nil
+}
+
+// ValidationHeadroom returns the number of empty rows past the data of the sheet covered by drop-down lists.
+func (s Settings) ValidationHeadroom(sheet string) (int, error) {
+	value := s.Get(sheet, SettingKeyValidationHeadroom)
+	if value == "" {
+		return DefaultValidationHeadroom, nil
+	}
+	headroom, err := strconv.Atoi(value)
+	if err != nil || headroom < 0 {
+		return 0, fmt.Errorf("sheet:%s invalid %s: %s", sheet, SettingKeyValidationHeadroom, value)
+	}
+	return headroom, nil
+}
+
+func (s Settings) Layout(sheet string) (Layout, error) {
+	var layout Layout
+	for _, v := range []struct {
+		key string
+		row *int
+	}{
+		{SettingKeyTypeRow, &layout.TypeRow},
+		{SettingKeyNameRow, &layout.NameRow},
+		{SettingKeyDescriptionRow, &layout.DescriptionRow},
+		{SettingKeyBodyRow, &layout.BodyRow},
+		{SettingKeyTargetRow, &layout.TargetRow},
+	} {
+		value := s.Get(sheet, v.key)
+		if value == "" {
+			continue
+		}
+		row, err := strconv.Atoi(value)
+		if err != nil || row < 1 {
+			return Layout{}, fmt.Errorf("sheet:%s invalid %s: %s", sheet, v.key, value)
+		}
+		*v.row = row
+	}
+	layout = layout.WithDefaults()
+	if err := layout.Validate(); err != nil {
+		return Layout{}, fmt.Errorf("sheet:%s %w", sheet, err)
+	}
+	return layout, nil
+}
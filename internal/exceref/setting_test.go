@@ -0,0 +1,111 @@
+package exceref_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daichirata/exceref/internal/exceref"
+)
+
+func TestNewSettings(t *testing.T) {
+	rows := [][]string{
+		{"sheet", "key", "value"},
+		{"", "body_row", "5"},
+		{"items", "body_row", "6"},
+		{"items", "description_row", "5"},
+		{"", "", ""},
+	}
+	settings, err := exceref.NewSettings(exceref.NewSettingSheet("_settings", rows))
+	require.NoError(t, err)
+	require.Len(t, settings, 3)
+	require.Equal(t, "5", settings.Get("users", "body_row"))
+	require.Equal(t, "6", settings.Get("items", "body_row"))
+	require.Equal(t, "", settings.Get("users", "description_row"))
+
+	layout, err := settings.Layout("users")
+	require.NoError(t, err)
+	require.Equal(t, exceref.Layout{TypeRow: 1, NameRow: 2, DescriptionRow: 3, BodyRow: 5}, layout)
+
+	layout, err = settings.Layout("items")
+	require.NoError(t, err)
+	require.Equal(t, exceref.Layout{TypeRow: 1, NameRow: 2, DescriptionRow: 5, BodyRow: 6}, layout)
+
+	_, err = exceref.NewSettings(exceref.NewSettingSheet("_settings", [][]string{
+		{"sheet", "key", "value"},
+		{"", "unknown", "1"},
+	}))
+	require.Error(t, err)
+}
+
+func TestSettings_LayoutInvalid(t *testing.T) {
+	for _, rows := range [][][]string{
+		{{"sheet", "key", "value"}, {"", "type_row", "x"}},
+		{{"sheet", "key", "value"}, {"", "name_row", "1"}},
+		{{"sheet", "key", "value"}, {"", "body_row", "3"}},
+	} {
+		settings, err := exceref.NewSettings(exceref.NewSettingSheet("_settings", rows))
+		require.NoError(t, err)
+		_, err = settings.Layout("items")
+		require.Error(t, err)
+	}
+}
+
+func TestSettings_TimeLayouts(t *testing.T) {
+	settings, err := exceref.NewSettings(exceref.NewSettingSheet("_settings", [][]string{
+		{"sheet", "key", "value"},
+		{"", "time_layouts", "02.01.2006 | Jan 2, 2006"},
+		{"events", "time_layouts", "2006年1月2日"},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"02.01.2006", "Jan 2, 2006"}, settings.TimeLayouts("items"))
+	require.Equal(t, []string{"2006年1月2日"}, settings.TimeLayouts("events"))
+	require.Nil(t, exceref.Settings{}.TimeLayouts("items"))
+}
+
+func TestSettings_Location(t *testing.T) {
+	settings, err := exceref.NewSettings(exceref.NewSettingSheet("_settings", [][]string{
+		{"sheet", "key", "value"},
+		{"", "timezone", "Asia/Tokyo"},
+		{"logs", "timezone", "UTC"},
+		{"broken", "timezone", "Mars/Olympus"},
+	}))
+	require.NoError(t, err)
+
+	loc, err := settings.Location("events")
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Tokyo", loc.String())
+	loc, err = settings.Location("logs")
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, loc)
+	_, err = settings.Location("broken")
+	require.Error(t, err)
+
+	loc, err = exceref.Settings{}.Location("events")
+	require.NoError(t, err)
+	require.Nil(t, loc)
+}
+
+func TestSettings_ValidationHeadroom(t *testing.T) {
+	settings, err := exceref.NewSettings(exceref.NewSettingSheet("_settings", [][]string{
+		{"sheet", "key", "value"},
+		{"", "validation_headroom", "100"},
+		{"logs", "validation_headroom", "0"},
+		{"broken", "validation_headroom", "-1"},
+	}))
+	require.NoError(t, err)
+
+	headroom, err := settings.ValidationHeadroom("items")
+	require.NoError(t, err)
+	require.Equal(t, 100, headroom)
+	headroom, err = settings.ValidationHeadroom("logs")
+	require.NoError(t, err)
+	require.Equal(t, 0, headroom)
+	_, err = settings.ValidationHeadroom("broken")
+	require.Error(t, err)
+
+	headroom, err = exceref.Settings{}.ValidationHeadroom("items")
+	require.NoError(t, err)
+	require.Equal(t, exceref.DefaultValidationHeadroom, headroom)
+}
@@ -1,10 +1,15 @@
 package exceref
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/daichirata/exceref/internal/errs"
 	"github.com/samber/lo"
 	"github.com/xuri/excelize/v2"
 )
@@ -32,20 +37,147 @@ const (
 	ColumnTypeRef      ColumnType = "ref"
 )
 
+const (
+	// ColumnTypeListPrefix marks a list type such as []int. The element separator defaults to
+	// DefaultListSeparator and can be overridden with a suffix, e.g. []string(;).
+	ColumnTypeListPrefix = "[]"
+	DefaultListSeparator = ","
+
+	// ColumnTypeNullableSuffix marks a nullable scalar type such as int?, whose empty cells are kept as nil.
+	ColumnTypeNullableSuffix = "?"
+
+	// ColumnTypeEnumPrefix marks an enum type such as enum:Rarity, whose members are defined in the _types sheet.
+	ColumnTypeEnumPrefix = "enum:"
+
+	// ColumnTypeObjectPrefix marks the type of a row of another sheet, such as object:items, embedded by a reference.
+	// It is not written in the type row but set when a reference is resolved.
+	ColumnTypeObjectPrefix = "object:"
+)
+
 func (c ColumnType) String() string {
 	return string(c)
 }
 
+func (c ColumnType) IsNullable() bool {
+	return strings.HasSuffix(string(c), ColumnTypeNullableSuffix)
+}
+
+// NonNull returns the type without the nullable suffix.
+func (c ColumnType) NonNull() ColumnType {
+	return ColumnType(strings.TrimSuffix(string(c), ColumnTypeNullableSuffix))
+}
+
+// Nullable returns the nullable form of the type.
+func (c ColumnType) Nullable() ColumnType {
+	if c == "" || c.IsNullable() {
+		return c
+	}
+	return c + ColumnTypeNullableSuffix
+}
+
+func (c ColumnType) IsEnum() bool {
+	return strings.HasPrefix(string(c), ColumnTypeEnumPrefix)
+}
+
+// EnumName returns the enum name of an enum type.
+func (c ColumnType) EnumName() string {
+	return strings.TrimPrefix(string(c), ColumnTypeEnumPrefix)
+}
+
+func (c ColumnType) IsObject() bool {
+	return strings.HasPrefix(string(c), ColumnTypeObjectPrefix)
+}
+
+// ObjectName returns the sheet name of an object type.
+func (c ColumnType) ObjectName() string {
+	return strings.TrimPrefix(string(c), ColumnTypeObjectPrefix)
+}
+
+// ObjectOf returns the object type of the rows of the sheet.
+func ObjectOf(sheet string) ColumnType {
+	return ColumnType(ColumnTypeObjectPrefix + sheet)
+}
+
+func (c ColumnType) IsList() bool {
+	return strings.HasPrefix(string(c), ColumnTypeListPrefix)
+}
+
+// Elem returns the element type of a list type, or the type itself otherwise.
+func (c ColumnType) Elem() ColumnType {
+	if !c.IsList() {
+		return c
+	}
+	elem, _ := splitListType(strings.TrimPrefix(string(c), ColumnTypeListPrefix))
+	return ColumnType(elem)
+}
+
+// Separator returns the element separator of a list type.
+func (c ColumnType) Separator() string {
+	if !c.IsList() {
+		return ""
+	}
+	_, sep := splitListType(strings.TrimPrefix(string(c), ColumnTypeListPrefix))
+	return sep
+}
+
+// ListOf returns the list type of the element type, keeping a non-default separator in the type.
+func ListOf(elem ColumnType, separator string) ColumnType {
+	if separator == DefaultListSeparator {
+		return ColumnTypeListPrefix + elem
+	}
+	return ColumnType(fmt.Sprintf("%s%s(%s)", ColumnTypeListPrefix, elem, separator))
+}
+
+func splitListType(s string) (string, string) {
+	if i := strings.Index(s, "("); i >= 0 && strings.HasSuffix(s, ")") {
+		return s[:i], s[i+1 : len(s)-1]
+	}
+	return s, DefaultListSeparator
+}
+
 func NewColumnType(s string) (ColumnType, error) {
-	switch ColumnType(s) {
-	case ColumnTypeString, ColumnTypeFloat, ColumnTypeInt, ColumnTypeBool,
-		ColumnTypeDatetime, ColumnTypeDate, ColumnTypeUnixtime, ColumnTypeRef:
-		return ColumnType(s), nil
-	case "":
+	c := ColumnType(s)
+	switch {
+	case c == "":
 		return "", nil
+	case c.IsNullable():
+		if c.NonNull().IsList() {
+			return "", fmt.Errorf("list type cannot be nullable: %s", s)
+		}
+		if c.NonNull() != ColumnTypeRef && !c.NonNull().isScalar() {
+			return "", fmt.Errorf("unknown column type: %s", s)
+		}
+	case c.IsList():
+		if c.Separator() == "" {
+			return "", fmt.Errorf("empty list separator: %s", s)
+		}
+		if c.Elem() != ColumnTypeRef && !c.Elem().isScalar() {
+			return "", fmt.Errorf("unknown list element type: %s", s)
+		}
 	default:
-		return "", fmt.Errorf("unknown column type: %s", s)
+		if c != ColumnTypeRef && !c.isScalar() {
+			return "", fmt.Errorf("unknown column type: %s", s)
+		}
+	}
+	return c, nil
+}
+
+// usesRawValue reports whether cells of the type are read without their number format.
+func (c ColumnType) usesRawValue() bool {
+	switch c.NonNull() {
+	case ColumnTypeInt, ColumnTypeFloat, ColumnTypeDatetime, ColumnTypeDate, ColumnTypeUnixtime:
+		return true
+	}
+	return false
+}
+
+func (c ColumnType) isScalar() bool {
+	switch c {
+	case ColumnTypeString, ColumnTypeFloat, ColumnTypeInt, ColumnTypeBool,
+		ColumnTypeDatetime, ColumnTypeDate, ColumnTypeUnixtime:
+		return true
 	}
+	return c.IsEnum() && c.EnumName() != ""
 }
 
 type Column struct {
@@ -53,12 +185,24 @@ type Column struct {
 	Type        ColumnType
 	Index       int
 	Description string
+	// Targets lists the build targets, such as client or server, the column is exported to.
+	// A column without targets is exported to every target.
+	Targets []string
+
+	// comment marks a column skipped by the comment marker, which keeps its name but has no type.
+	comment bool
+	// embed is the embed reference resolved into the column, whose rows are rebuilt for a target by ForTarget.
+	embed *Reference
 }
 
 func (c *Column) IsExportable() bool {
 	return c.Name != "" && c.Type != ""
 }
 
+func (c *Column) HasTarget(target string) bool {
+	return target == "" || len(c.Targets) == 0 || lo.Contains(c.Targets, target)
+}
+
 type Row []*Cell
 
 func (r Row) Cells(names ...string) []*Cell {
@@ -79,10 +223,125 @@ type Cell struct {
 	Raw    string
 }
 
+// CompositeKeySeparator joins the values of a composite key where they are shown or written in a single cell.
+const CompositeKeySeparator = "|"
+
+// keyValues returns the raw values of the columns in the row.
+func keyValues(row Row, columns []*Column) []string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = row[column.Index].Raw
+	}
+	return values
+}
+
+// joinKey joins the values of a key with CompositeKeySeparator. Joined keys are for display only, since values
+// holding the separator make them ambiguous: x|y and z join to the same key as x and y|z.
+func joinKey(values []string) string {
+	return strings.Join(values, CompositeKeySeparator)
+}
+
+// encodeKey encodes the values of a key for matching. A single value is kept as it is, and the values of a
+// composite key are encoded as a JSON array.
+func encodeKey(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
 type Sheet struct {
-	Name    string
-	Columns []*Column
-	Rows    []Row
+	Name       string
+	Columns    []*Column
+	Rows       []Row
+	PrimaryKey []*Column
+	Layout     Layout
+	// RowNumbers holds the 1-based row numbers in the workbook of Rows, which skip comment rows.
+	RowNumbers []int
+
+	resolving bool
+	resolved  bool
+	// resolveErr is the result of the resolution, returned again when the sheet is resolved once more.
+	resolveErr error
+	// unresolvedRows and unresolvedTypes keep the cells and column types as loaded, before the resolution
+	// rewrites them, so that references to the sheet read the same keys and values either way.
+	unresolvedRows  []Row
+	unresolvedTypes []ColumnType
+}
+
+// snapshot keeps the cells and column types of the sheet before it is resolved.
+func (s *Sheet) snapshot() {
+	s.unresolvedRows = make([]Row, len(s.Rows))
+	for i, row := range s.Rows {
+		s.unresolvedRows[i] = append(Row(nil), row...)
+	}
+	s.unresolvedTypes = make([]ColumnType, len(s.Columns))
+	for i, column := range s.Columns {
+		s.unresolvedTypes[i] = column.Type
+	}
+}
+
+// unresolved returns the rows of the sheet and the column of index as they were before the resolution.
+func (s *Sheet) unresolved(index int) ([]Row, Column) {
+	column := *s.Columns[index]
+	if s.unresolvedRows == nil {
+		return s.Rows, column
+	}
+	if index < len(s.unresolvedTypes) {
+		column.Type = s.unresolvedTypes[index]
+	}
+	return s.unresolvedRows, column
+}
+
+// RowNumber returns the 1-based row number in the workbook of the i-th data row.
+func (s *Sheet) RowNumber(i int) int {
+	if i < len(s.RowNumbers) {
+		return s.RowNumbers[i]
+	}
+	return s.Layout.WithDefaults().BodyRow + i
+}
+
+// CellError returns an error located at the cell of the column in the 1-based row.
+// The error points at the whole row if column is nil, or at the whole sheet if row is zero.
+func (s *Sheet) CellError(row int, column *Column, err error) error {
+	var cell string
+	switch {
+	case row > 0 && column != nil:
+		cell, _ = excelize.CoordinatesToCellName(column.Index+1, row)
+	case row > 0:
+		cell = strconv.Itoa(row)
+	}
+	return &errs.CellError{Sheet: s.Name, Cell: cell, Err: err}
+}
+
+// Key returns the primary key of the row, joining the values of a composite key.
+func (s *Sheet) Key(row Row) string {
+	return joinKey(keyValues(row, s.PrimaryKey))
+}
+
+// ValidatePrimaryKey reports rows whose primary key is duplicated or has an empty value.
+func (s *Sheet) ValidatePrimaryKey() error {
+	if len(s.PrimaryKey) == 0 {
+		return nil
+	}
+	var failures []error
+	seen := make(map[string]int)
+	for i, row := range s.Rows {
+		values := keyValues(row, s.PrimaryKey)
+		if j := lo.IndexOf(values, ""); j >= 0 {
+			failures = append(failures, s.CellError(s.RowNumber(i), s.PrimaryKey[j], errors.New("primary key is empty")))
+			continue
+		}
+		key := encodeKey(values)
+		if first, ok := seen[key]; ok {
+			failures = append(failures, s.CellError(s.RowNumber(i), s.PrimaryKey[0],
+				fmt.Errorf("primary key:%s is duplicated with row:%d", joinKey(values), first)))
+			continue
+		}
+		seen[key] = s.RowNumber(i)
+	}
+	return errors.Join(failures...)
 }
 
 func (s *Sheet) Column(name string) (*Column, error) {
@@ -104,32 +363,105 @@ func (s *Sheet) Map() []map[string]any {
 			if !column.IsExportable() {
 				continue
 			}
-			data[i][column.Name] = row[column.Index].Value
+			path, err := parseColumnPath(column.Name)
+			if err != nil {
+				data[i][column.Name] = row[column.Index].Value
+				continue
+			}
+			setColumnPath(data[i], path, row[column.Index].Value)
 		}
 	}
 	return data
 }
 
-func (s *Sheet) Sqrefs(referenceDefinition *ReferenceDefinition) (string, string, error) {
-	column, err := s.Column(referenceDefinition.Column)
+// ForTarget returns a copy of the sheet without the columns that are not exported to the target.
+func (s *Sheet) ForTarget(target string) *Sheet {
+	sheet := *s
+	sheet.Columns = lo.Filter(s.Columns, func(c *Column, _ int) bool {
+		return c.HasTarget(target)
+	})
+	sheet.PrimaryKey = lo.Filter(s.PrimaryKey, func(c *Column, _ int) bool {
+		return c.HasTarget(target)
+	})
+
+	// Embedded rows hold every column of the referenced rows, so they are rebuilt from the referenced sheets
+	// for the target as well.
+	embeds := lo.Filter(sheet.Columns, func(c *Column, _ int) bool {
+		return c.embed != nil
+	})
+	if target == "" || len(embeds) == 0 {
+		return &sheet
+	}
+	sheet.Rows = lo.Map(s.Rows, func(row Row, _ int) Row {
+		return append(Row{}, row...)
+	})
+	for _, column := range embeds {
+		referenceSheet := column.embed.Sheet.ForTarget(target)
+		for _, row := range sheet.Rows {
+			row[column.Index] = column.embed.embedCell(referenceSheet, column, row[column.Index])
+		}
+	}
+	return &sheet
+}
+
+// KeyedMap returns the rows of Map keyed by the primary key.
+func (s *Sheet) KeyedMap() (map[string]map[string]any, error) {
+	return s.keyedMap(s.Map())
+}
+
+func (s *Sheet) keyedMap(rows []map[string]any) (map[string]map[string]any, error) {
+	if len(s.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("sheet:%s primary key not declared", s.Name)
+	}
+	data := make(map[string]map[string]any, len(s.Rows))
+	for i, m := range rows {
+		key := s.Key(s.Rows[i])
+		if _, ok := data[key]; ok {
+			return nil, s.CellError(s.RowNumber(i), s.PrimaryKey[0], fmt.Errorf("primary key:%s is ambiguous when joined", key))
+		}
+		data[key] = m
+	}
+	return data, nil
+}
+
+// ColumnSqref returns the range of the column from the first data row to headroom rows past the last one.
+func (s *Sheet) ColumnSqref(column *Column, headroom int) (string, error) {
+	bodyRow := s.Layout.WithDefaults().BodyRow
+	first, err := excelize.CoordinatesToCellName(column.Index+1, bodyRow)
 	if err != nil {
-		return "", "", err
+		return "", err
+	}
+	lastRow := bodyRow - 1
+	if len(s.RowNumbers) > 0 {
+		lastRow = s.RowNumbers[len(s.RowNumbers)-1]
+	} else if len(s.Rows) > 0 {
+		lastRow = bodyRow + len(s.Rows) - 1
 	}
-	first, err := excelize.CoordinatesToCellName(column.Index+1, DataSheetIndexBody+1)
+	last, err := excelize.CoordinatesToCellName(column.Index+1, min(max(lastRow+headroom, bodyRow), excelize.TotalRows))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s", first, last), nil
+}
+
+// Sqrefs returns the range of the referencing column and the range of the keyCount keys written to the
+// _reference_data sheet for the reference.
+func (s *Sheet) Sqrefs(referenceDefinition *ReferenceDefinition, keyCount, headroom int) (string, string, error) {
+	column, err := s.Column(lo.FirstOrEmpty(referenceDefinition.Columns()))
 	if err != nil {
 		return "", "", err
 	}
-	last, err := excelize.CoordinatesToCellName(column.Index+1, 9999)
+	sqref, err := s.ColumnSqref(column, headroom)
 	if err != nil {
 		return "", "", err
 	}
-	sqref := fmt.Sprintf("%s:%s", first, last)
 
 	srcFirst, err := excelize.CoordinatesToCellName(referenceDefinition.Index+1, 1, true)
 	if err != nil {
 		return "", "", err
 	}
-	srcLast, err := excelize.CoordinatesToCellName(referenceDefinition.Index+1, 9999, true)
+	// Keep a valid single-cell range even when the reference source has no rows.
+	srcLast, err := excelize.CoordinatesToCellName(referenceDefinition.Index+1, max(keyCount, 1), true)
 	if err != nil {
 		return "", "", err
 	}
@@ -138,46 +470,179 @@ func (s *Sheet) Sqrefs(referenceDefinition *ReferenceDefinition) (string, string
 	return sqref, srcSqref, nil
 }
 
-func NewDataSeet(name string, rows [][]string) (*Sheet, error) {
+// Layout is the 1-based row numbers of the header rows and the first data row of a data sheet.
+// Rows that are not part of the layout, such as notes above the data, are ignored.
+type Layout struct {
+	TypeRow        int
+	NameRow        int
+	DescriptionRow int
+	BodyRow        int
+	// TargetRow is the optional row of column targets such as client or server. Zero means no target row.
+	TargetRow int
+}
+
+var DefaultLayout = Layout{
+	TypeRow:        DataSheetIndexColumnType + 1,
+	NameRow:        DataSheetIndexColumnName + 1,
+	DescriptionRow: DataSheetIndexColumnDescription + 1,
+	BodyRow:        DataSheetIndexBody + 1,
+}
+
+// WithDefaults fills the unset rows with DefaultLayout.
+func (l Layout) WithDefaults() Layout {
+	if l.TypeRow == 0 {
+		l.TypeRow = DefaultLayout.TypeRow
+	}
+	if l.NameRow == 0 {
+		l.NameRow = DefaultLayout.NameRow
+	}
+	if l.DescriptionRow == 0 {
+		l.DescriptionRow = DefaultLayout.DescriptionRow
+	}
+	if l.BodyRow == 0 {
+		l.BodyRow = DefaultLayout.BodyRow
+	}
+	return l
+}
+
+func (l Layout) Validate() error {
+	headers := []int{l.TypeRow, l.NameRow, l.DescriptionRow}
+	if l.TargetRow != 0 {
+		headers = append(headers, l.TargetRow)
+	}
+	if len(lo.Uniq(headers)) != len(headers) {
+		return fmt.Errorf("header rows must be different: type_row:%d name_row:%d description_row:%d target_row:%d",
+			l.TypeRow, l.NameRow, l.DescriptionRow, l.TargetRow)
+	}
+	if l.BodyRow <= lo.Max(headers) {
+		return fmt.Errorf("body_row:%d must follow the header rows", l.BodyRow)
+	}
+	return nil
+}
+
+// DefaultCommentMarker starts rows and column names that are skipped.
+const DefaultCommentMarker = "#"
+
+type SheetOption struct {
+	// Enums holds the enum definitions of the workbook keyed by name.
+	Enums map[string]*Enum
+	// Layout is the header layout of the sheet. DefaultLayout is used for unset rows.
+	Layout Layout
+	// CommentMarker skips rows whose first cell and columns whose name start with it.
+	// DefaultCommentMarker is used if empty.
+	CommentMarker string
+	// RawRows holds the cell values without number formats applied, in the same shape as the rows.
+	// Numeric columns are parsed from them when set, so that formats such as 1,000 or 50% are accepted.
+	RawRows [][]string
+	// TimeLayouts are extra layouts accepted by datetime, date and unixtime columns.
+	TimeLayouts []string
+	// Date1904 interprets Excel serial dates with the 1904 date system.
+	Date1904 bool
+	// Location is the timezone of time values without an offset, including Excel date cells. UTC is used if nil.
+	Location *time.Location
+}
+
+func (o SheetOption) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+func (o SheetOption) value(rows [][]string, i int, column *Column) string {
+	if o.RawRows != nil && column.Type.usesRawValue() {
+		if row := lo.NthOr(o.RawRows, i, nil); column.Index < len(row) {
+			return row[column.Index]
+		}
+	}
+	return lo.NthOr(rows[i], column.Index, "")
+}
+
+func (o SheetOption) commentMarker() string {
+	if o.CommentMarker == "" {
+		return DefaultCommentMarker
+	}
+	return o.CommentMarker
+}
+
+func NewDataSeet(name string, rows [][]string, option SheetOption) (*Sheet, error) {
+	layout := option.Layout.WithDefaults()
 	sheet := &Sheet{
-		Name: name,
+		Name:   name,
+		Layout: layout,
 	}
-	for i, r := range rows {
-		switch i {
-		case DataSheetIndexColumnType:
-			for j, value := range r {
-				columnType, err := NewColumnType(value)
-				if err != nil {
-					return nil, err
-				}
-				sheet.Columns = append(sheet.Columns, &Column{
-					Type:  columnType,
-					Index: j,
-				})
-			}
-		case DataSheetIndexColumnName:
-			for j, value := range r {
-				sheet.Columns[j].Name = value
+	header := func(row int) []string {
+		return lo.NthOr(rows, row-1, nil)
+	}
+
+	marker := option.commentMarker()
+	names := header(layout.NameRow)
+	var failures []error
+
+	for j, value := range header(layout.TypeRow) {
+		if columnName := lo.NthOr(names, j, ""); strings.HasPrefix(columnName, marker) {
+			slog.Debug("skip comment column", "sheet", name, "column", columnName)
+			sheet.Columns = append(sheet.Columns, &Column{Index: j, comment: true})
+			continue
+		}
+		column := &Column{Index: j}
+		sheet.Columns = append(sheet.Columns, column)
+
+		columnType, err := NewColumnType(value)
+		if err != nil {
+			failures = append(failures, sheet.CellError(layout.TypeRow, column, err))
+			continue
+		}
+		if enumType := columnType.Elem().NonNull(); enumType.IsEnum() {
+			if _, ok := option.Enums[enumType.EnumName()]; !ok {
+				failures = append(failures, sheet.CellError(layout.TypeRow, column, fmt.Errorf("enum:%s not defined", enumType.EnumName())))
+				continue
 			}
-		case DataSheetIndexColumnDescription:
-			for j, value := range r {
-				sheet.Columns[j].Description = value
+		}
+		column.Type = columnType
+	}
+	for j, value := range names {
+		if j < len(sheet.Columns) {
+			sheet.Columns[j].Name = value
+		}
+	}
+	if _, err := newColumnTree(sheet.Columns); err != nil {
+		failures = append(failures, sheet.CellError(0, nil, err))
+	}
+	for j, value := range header(layout.DescriptionRow) {
+		if j < len(sheet.Columns) {
+			sheet.Columns[j].Description = value
+		}
+	}
+	if layout.TargetRow != 0 {
+		for j, value := range header(layout.TargetRow) {
+			if j < len(sheet.Columns) && value != "" {
+				sheet.Columns[j].Targets = lo.Map(strings.Split(value, ","), func(s string, _ int) string {
+					return strings.TrimSpace(s)
+				})
 			}
-		default:
-			var row Row
-			for _, column := range sheet.Columns {
-				var rawValue string
-				if len(r) > column.Index {
-					rawValue = r[column.Index]
-				}
-				value, err := parseValue(column.Type, rawValue)
-				if err != nil {
-					return nil, err
-				}
-				row = append(row, &Cell{Column: column, Value: value, Raw: rawValue})
+		}
+	}
+
+	for i := layout.BodyRow - 1; i < len(rows); i++ {
+		if strings.HasPrefix(lo.NthOr(rows[i], 0, ""), marker) {
+			slog.Debug("skip comment row", "sheet", name, "row", i+1)
+			continue
+		}
+		var row Row
+		for _, column := range sheet.Columns {
+			rawValue := option.value(rows, i, column)
+			value, err := parseValue(column.Type, rawValue, option)
+			if err != nil {
+				failures = append(failures, sheet.CellError(i+1, column, fmt.Errorf("column:%s %w", column.Name, err)))
 			}
-			sheet.Rows = append(sheet.Rows, row)
+			row = append(row, &Cell{Column: column, Value: value, Raw: rawValue})
 		}
+		sheet.Rows = append(sheet.Rows, row)
+		sheet.RowNumbers = append(sheet.RowNumbers, i+1)
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
 	}
 	return sheet, nil
 }
@@ -209,15 +674,44 @@ func NewReferenceDefinitionSheet(name string, rows [][]string) *Sheet {
 				})
 			}
 			sheet.Rows = append(sheet.Rows, row)
+			sheet.RowNumbers = append(sheet.RowNumbers, i+1)
 		}
 	}
 	return sheet
 }
 
-func parseValue(columnType ColumnType, value string) (any, error) {
+// NewConstraintDefinitionSheet parses the _constraints sheet, which shares the layout of the _references sheet.
+func NewConstraintDefinitionSheet(name string, rows [][]string) *Sheet {
+	return NewReferenceDefinitionSheet(name, rows)
+}
+
+// NewSettingSheet parses the _settings sheet, which shares the layout of the _references sheet.
+func NewSettingSheet(name string, rows [][]string) *Sheet {
+	return NewReferenceDefinitionSheet(name, rows)
+}
+
+func parseValue(columnType ColumnType, value string, option SheetOption) (any, error) {
 	if columnType == "" {
 		return "", nil
 	}
+	if columnType.IsList() {
+		return parseList(columnType, value, option)
+	}
+	if columnType.IsNullable() {
+		if value == "" {
+			return nil, nil
+		}
+		return parseValue(columnType.NonNull(), value, option)
+	}
+	if columnType.IsEnum() {
+		if value == "" {
+			return "", nil
+		}
+		if !option.Enums[columnType.EnumName()].Contains(value) {
+			return nil, fmt.Errorf("enum:%s unknown member:%s", columnType.EnumName(), value)
+		}
+		return value, nil
+	}
 	if value == "" {
 		switch columnType {
 		case ColumnTypeString:
@@ -249,15 +743,15 @@ func parseValue(columnType ColumnType, value string) (any, error) {
 	case ColumnTypeBool:
 		return strconv.ParseBool(value)
 	case ColumnTypeDatetime:
-		return time.Parse(time.RFC3339, value)
+		return parseTime(value, option)
 	case ColumnTypeDate:
-		t, err := time.ParseInLocation(time.DateOnly, value, time.UTC)
+		t, err := parseTime(value, option)
 		if err != nil {
 			return nil, err
 		}
 		return t.Format(time.DateOnly), nil
 	case ColumnTypeUnixtime:
-		t, err := time.Parse(time.RFC3339, value)
+		t, err := parseTime(value, option)
 		if err != nil {
 			return nil, err
 		}
@@ -267,3 +761,51 @@ func parseValue(columnType ColumnType, value string) (any, error) {
 	}
 	return nil, fmt.Errorf("unmatched type:%s", columnType)
 }
+
+// DefaultTimeLayouts are the layouts accepted by datetime, date and unixtime columns.
+// Single digit months and days are accepted as well, e.g. 2024/1/2.
+var DefaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-1-2 15:04:05",
+	"2006-1-2 15:04",
+	"2006-1-2",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+}
+
+// parseTime parses a string in one of the time layouts, or an Excel serial date such as 45293.5.
+// Values without an offset are interpreted in the location of the option.
+func parseTime(value string, option SheetOption) (time.Time, error) {
+	loc := option.location()
+	for _, layouts := range [][]string{option.TimeLayouts, DefaultTimeLayouts} {
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		t, err := excelize.ExcelDateToTime(serial, option.Date1904)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time:%s", value)
+}
+
+func parseList(columnType ColumnType, value string, option SheetOption) (any, error) {
+	values := []any{}
+	if value == "" {
+		return values, nil
+	}
+	for _, s := range strings.Split(value, columnType.Separator()) {
+		v, err := parseValue(columnType.Elem(), strings.TrimSpace(s), option)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/daichirata/exceref/internal/errs"
 	"github.com/daichirata/exceref/internal/exceref"
 )
 
@@ -77,10 +78,19 @@ func TestSheet_Sqrefs(t *testing.T) {
 		Index:  0,
 		Column: "column_b",
 	}
-	dst, src, err := sheet.Sqrefs(referenceDefinition)
+	dst, src, err := sheet.Sqrefs(referenceDefinition, 12000, 10)
 	require.NoError(t, err)
-	require.Equal(t, "B4:B9999", dst)
-	require.Equal(t, "$A$1:$A$9999", src)
+	require.Equal(t, "B4:B15", dst)
+	require.Equal(t, "$A$1:$A$12000", src)
+
+	dst, src, err = sheet.Sqrefs(referenceDefinition, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, "B4:B5", dst)
+	require.Equal(t, "$A$1:$A$1", src)
+
+	dst, err = sheet.ColumnSqref(columns[1], 2000000)
+	require.NoError(t, err)
+	require.Equal(t, "B4:B1048576", dst)
 }
 
 func TestNewDataSeet(t *testing.T) {
@@ -93,7 +103,7 @@ func TestNewDataSeet(t *testing.T) {
 		{"c", "3", "buzz", "0.3", "true", "2024-01-03T00:00:00Z", "2024-01-03", "2024-01-03T00:00:00Z", "ref_a"},
 		{"", "", "", "", "", "", "", "", ""},
 	}
-	sheet, err := exceref.NewDataSeet("test_sheet", rows)
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{})
 	require.NoError(t, err)
 	require.Equal(t, sheet.Name, "test_sheet")
 	require.Equal(t, []exceref.Row{
@@ -143,7 +153,7 @@ func TestNewDataSeet(t *testing.T) {
 		},
 	}, sheet.Rows)
 
-	sheet, err = exceref.NewDataSeet("test_sheet", rows[:exceref.DataSheetIndexBody])
+	sheet, err = exceref.NewDataSeet("test_sheet", rows[:exceref.DataSheetIndexBody], exceref.SheetOption{})
 	require.NoError(t, err)
 	require.Len(t, sheet.Rows, 0)
 }
@@ -179,3 +189,312 @@ func TestNewReferenceDefinitionSheet(t *testing.T) {
 	sheet = exceref.NewReferenceDefinitionSheet("test_sheet", rows[:exceref.ReferenceDefinitionSheetIndexBody])
 	require.Len(t, sheet.Rows, 0)
 }
+
+func TestNewColumnType_List(t *testing.T) {
+	columnType, err := exceref.NewColumnType("[]int")
+	require.NoError(t, err)
+	require.True(t, columnType.IsList())
+	require.Equal(t, exceref.ColumnTypeInt, columnType.Elem())
+	require.Equal(t, ",", columnType.Separator())
+
+	columnType, err = exceref.NewColumnType("[]string(;)")
+	require.NoError(t, err)
+	require.Equal(t, exceref.ColumnTypeString, columnType.Elem())
+	require.Equal(t, ";", columnType.Separator())
+
+	columnType, err = exceref.NewColumnType("[]ref(;)")
+	require.NoError(t, err)
+	require.Equal(t, exceref.ColumnTypeRef, columnType.Elem())
+	require.Equal(t, columnType, exceref.ListOf(exceref.ColumnTypeRef, ";"))
+	require.Equal(t, exceref.ColumnType("[]int"), exceref.ListOf(exceref.ColumnTypeInt, ","))
+
+	_, err = exceref.NewColumnType("[]ref?")
+	require.Error(t, err)
+	_, err = exceref.NewColumnType("[]int()")
+	require.Error(t, err)
+}
+
+func TestNewDataSeet_List(t *testing.T) {
+	rows := [][]string{
+		{"[]int", "[]string(;)", "[]float"},
+		{"column_a", "column_b", "column_c"},
+		{"", "", ""},
+		{"1, 2,3", "a;b", "0.5"},
+		{"", "", ""},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"column_a": []any{1, 2, 3}, "column_b": []any{"a", "b"}, "column_c": []any{0.5}},
+		{"column_a": []any{}, "column_b": []any{}, "column_c": []any{}},
+	}, sheet.Map())
+
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"[]int"}, {"column_a"}, {""}, {"1,x"}}, exceref.SheetOption{})
+	require.Error(t, err)
+}
+
+func TestNewDataSeet_Nullable(t *testing.T) {
+	rows := [][]string{
+		{"int?", "bool?", "datetime?", "date?", "string?"},
+		{"column_a", "column_b", "column_c", "column_d", "column_e"},
+		{"", "", "", "", ""},
+		{"0", "false", "2024-01-01T00:00:00Z", "2024-01-01", "a"},
+		{"", "", "", "", ""},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"column_a": 0, "column_b": false, "column_c": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "column_d": "2024-01-01", "column_e": "a"},
+		{"column_a": nil, "column_b": nil, "column_c": nil, "column_d": nil, "column_e": nil},
+	}, sheet.Map())
+
+	_, err = exceref.NewColumnType("[]int?")
+	require.Error(t, err)
+	_, err = exceref.NewColumnType("foo?")
+	require.Error(t, err)
+}
+
+func TestNewDataSeet_Enum(t *testing.T) {
+	option := exceref.SheetOption{
+		Enums: map[string]*exceref.Enum{
+			"rarity": {Name: "rarity", Members: []string{"common", "rare"}},
+		},
+	}
+	rows := [][]string{
+		{"enum:rarity", "enum:rarity?", "[]enum:rarity"},
+		{"column_a", "column_b", "column_c"},
+		{"", "", ""},
+		{"common", "", "common,rare"},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, option)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"column_a": "common", "column_b": nil, "column_c": []any{"common", "rare"}},
+	}, sheet.Map())
+
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"enum:rarity"}, {"column_a"}, {""}, {"epic"}}, option)
+	require.Error(t, err)
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"enum:element"}, {"column_a"}}, option)
+	require.Error(t, err)
+}
+
+func TestSheet_MapNested(t *testing.T) {
+	rows := [][]string{
+		{"int", "int", "int", "string", "int", "string", "string"},
+		{"id", "reward.item_id", "reward.amount", "drops[0].id", "drops[0].rate", "drops[1].id", "tags[1]"},
+		{},
+		{"1", "10", "2", "a", "50", "b", "x"},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{
+			"id":     1,
+			"reward": map[string]any{"item_id": 10, "amount": 2},
+			"drops": []any{
+				map[string]any{"id": "a", "rate": 50},
+				map[string]any{"id": "b"},
+			},
+			"tags": []any{nil, "x"},
+		},
+	}, sheet.Map())
+
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"int", "int"}, {"reward", "reward.id"}}, exceref.SheetOption{})
+	require.Error(t, err)
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"int", "int"}, {"reward.id", "reward[0].id"}}, exceref.SheetOption{})
+	require.Error(t, err)
+	_, err = exceref.NewDataSeet("test_sheet", [][]string{{"int", "int"}, {"id", "id"}}, exceref.SheetOption{})
+	require.Error(t, err)
+}
+
+func TestSheet_ValidatePrimaryKey(t *testing.T) {
+	rows := [][]string{
+		{"int", "int", "string"},
+		{"stage_id", "wave", "name"},
+		{},
+		{"1", "1", "a"},
+		{"1", "2", "b"},
+		{"1", "1", "c"},
+	}
+	sheet, err := exceref.NewDataSeet("waves", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+	require.NoError(t, sheet.ValidatePrimaryKey())
+
+	sheet.PrimaryKey = sheet.Columns[:2]
+	require.EqualError(t, sheet.ValidatePrimaryKey(), "waves!A6: primary key:1|1 is duplicated with row:4")
+
+	sheet.Rows = sheet.Rows[:2]
+	require.NoError(t, sheet.ValidatePrimaryKey())
+	data, err := sheet.KeyedMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]any{
+		"1|1": {"stage_id": 1, "wave": 1, "name": "a"},
+		"1|2": {"stage_id": 1, "wave": 2, "name": "b"},
+	}, data)
+}
+
+func TestSheet_ValidatePrimaryKey_Separator(t *testing.T) {
+	sheet, err := exceref.NewDataSeet("codes", [][]string{
+		{"string", "string"},
+		{"group", "code"},
+		{},
+		{"x|y", "z"},
+		{"x", "y|z"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	sheet.PrimaryKey = sheet.Columns
+
+	require.NoError(t, sheet.ValidatePrimaryKey())
+	_, err = sheet.KeyedMap()
+	require.EqualError(t, err, "codes!A5: primary key:x|y|z is ambiguous when joined")
+
+	// A key made of the separator is a value, and a composite key with an empty value is empty.
+	sheet, err = exceref.NewDataSeet("codes", [][]string{
+		{"string", "string"},
+		{"group", "code"},
+		{},
+		{"|", "z"},
+		{"", "2"},
+	}, exceref.SheetOption{})
+	require.NoError(t, err)
+	sheet.PrimaryKey = sheet.Columns[:1]
+	require.EqualError(t, sheet.ValidatePrimaryKey(), "codes!A5: primary key is empty")
+	sheet.PrimaryKey = sheet.Columns
+	require.EqualError(t, sheet.ValidatePrimaryKey(), "codes!A5: primary key is empty")
+}
+
+func TestNewDataSeet_Layout(t *testing.T) {
+	rows := [][]string{
+		{"id", "name"},
+		{"client", "server"},
+		{"int", "string"},
+		{"ID", "Name"},
+		{"1", "a"},
+	}
+	option := exceref.SheetOption{
+		Layout: exceref.Layout{TypeRow: 3, NameRow: 1, DescriptionRow: 4, BodyRow: 5},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, option)
+	require.NoError(t, err)
+	require.Equal(t, "id", sheet.Columns[0].Name)
+	require.Equal(t, exceref.ColumnTypeInt, sheet.Columns[0].Type)
+	require.Equal(t, "ID", sheet.Columns[0].Description)
+	require.Equal(t, []map[string]any{{"id": 1, "name": "a"}}, sheet.Map())
+	require.Equal(t, 5, sheet.RowNumber(0))
+
+	dst, _, err := sheet.Sqrefs(&exceref.ReferenceDefinition{Column: "name"}, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, "B5:B5", dst)
+}
+
+func TestSheet_ForTarget(t *testing.T) {
+	rows := [][]string{
+		{"int", "string", "int"},
+		{"id", "name", "drop_rate"},
+		{},
+		{"", "client", "server"},
+		{"1", "a", "10"},
+	}
+	option := exceref.SheetOption{Layout: exceref.Layout{TargetRow: 4, BodyRow: 5}}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, option)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server"}, sheet.Columns[2].Targets)
+
+	require.Equal(t, []map[string]any{{"id": 1, "name": "a"}}, sheet.ForTarget("client").Map())
+	require.Equal(t, []map[string]any{{"id": 1, "drop_rate": 10}}, sheet.ForTarget("server").Map())
+	require.Equal(t, []map[string]any{{"id": 1, "name": "a", "drop_rate": 10}}, sheet.ForTarget("").Map())
+	require.Len(t, sheet.Columns, 3)
+}
+
+func TestNewDataSeet_Comment(t *testing.T) {
+	rows := [][]string{
+		{"int", "wip", "string"},
+		{"id", "#memo", "name"},
+		{},
+		{"1", "x", "a"},
+		{"#2", "y", "b"},
+		{"3", "z", "c"},
+	}
+	sheet, err := exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"id": 1, "name": "a"},
+		{"id": 3, "name": "c"},
+	}, sheet.Map())
+	require.Equal(t, 4, sheet.RowNumber(0))
+	require.Equal(t, 6, sheet.RowNumber(1))
+
+	rows[4][0] = "2"
+	rows[5][0] = "//3"
+	rows[1][1] = "//memo"
+	sheet, err = exceref.NewDataSeet("test_sheet", rows, exceref.SheetOption{CommentMarker: "//"})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"id": 1, "name": "a"},
+		{"id": 2, "name": "b"},
+	}, sheet.Map())
+}
+
+func TestNewDataSeet_Errors(t *testing.T) {
+	_, err := exceref.NewDataSeet("items", [][]string{
+		{"string", "int", "bool"},
+		{"id", "price", "sale"},
+		{},
+		{"a", "x", "true"},
+		{"b", "10", "maybe"},
+		{"c", "y", "false"},
+	}, exceref.SheetOption{})
+	require.Error(t, err)
+
+	var cells []string
+	for _, e := range errs.Flatten(err) {
+		var cellErr *errs.CellError
+		require.ErrorAs(t, e, &cellErr)
+		cells = append(cells, cellErr.Sheet+"!"+cellErr.Cell)
+	}
+	require.Equal(t, []string{"items!B4", "items!C5", "items!B6"}, cells)
+}
+
+func TestNewDataSeet_Time(t *testing.T) {
+	rows := [][]string{
+		{"datetime", "date", "unixtime"},
+		{"start_at", "day", "end_at"},
+		{},
+		{"2024/01/02 10:00", "2024/1/2", "2024-01-02 10:00:30"},
+		{"45293.5", "45293", "45293.25"},
+		{"02.01.2024", "Jan 2, 2024", "2024-01-02T10:00:00+09:00"},
+	}
+	sheet, err := exceref.NewDataSeet("events", rows, exceref.SheetOption{TimeLayouts: []string{"02.01.2006", "Jan 2, 2006"}})
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"start_at": time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), "day": "2024-01-02", "end_at": int64(1704189630)},
+		{"start_at": time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), "day": "2024-01-02", "end_at": int64(1704175200)},
+		{"start_at": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "day": "2024-01-02", "end_at": int64(1704157200)},
+	}, sheet.Map())
+
+	sheet, err = exceref.NewDataSeet("events", rows[:4], exceref.SheetOption{
+		RawRows:  [][]string{nil, nil, nil, {"43831.5", "43831", "0"}},
+		Date1904: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), sheet.Rows[0][0].Value)
+	require.Equal(t, "2024-01-02", sheet.Rows[0][1].Value)
+
+	_, err = exceref.NewDataSeet("events", [][]string{{"date"}, {"day"}, {}, {"someday"}}, exceref.SheetOption{})
+	require.Error(t, err)
+}
+
+func TestNewDataSeet_Location(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	rows := [][]string{
+		{"datetime", "unixtime", "datetime"},
+		{"start_at", "end_at", "utc_at"},
+		{},
+		{"2024-01-02 10:00", "45293.5", "2024-01-02T10:00:00Z"},
+	}
+	sheet, err := exceref.NewDataSeet("events", rows, exceref.SheetOption{Location: jst})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 2, 10, 0, 0, 0, jst), sheet.Rows[0][0].Value)
+	require.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, jst).Unix(), sheet.Rows[0][1].Value)
+	require.Equal(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), sheet.Rows[0][2].Value)
+}
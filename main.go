@@ -1,6 +1,9 @@
 package main
 
 import (
+	// Embed the timezone database for --timezone and the timezone setting on systems without one.
+	_ "time/tzdata"
+
 	"github.com/daichirata/exceref/cmd"
 )
 